@@ -13,8 +13,11 @@ import (
 )
 
 type GenerateFlags struct {
-	vars  map[string]string
-	debug bool
+	vars     map[string]string
+	debug    bool
+	sort     bool
+	cacheDir string
+	offline  bool
 }
 
 var genFlags GenerateFlags
@@ -40,13 +43,26 @@ var generateCmd = &cobra.Command{
 
 		dir := filepath.Dir(forgeFile)
 		refStore := generator.NewStore(vars)
+		refStore.SetCache(genFlags.cacheDir, genFlags.offline)
 		state := generator.NewPipeline(dir, cfg.PipelineGenerator, refStore, genFlags.debug)
 		result, err := state.Generate(cmd.Context())
 		if err != nil {
-			return err
+			return refStore.RedactError(err)
+		}
+
+		if genFlags.sort {
+			result, err = generator.SortResult(result)
+			if err != nil {
+				return fmt.Errorf("error sorting output: %w", err)
+			}
 		}
 
-		resultBytes, err := generator.ConvertToBytes(result)
+		var resultBytes []byte
+		if cfg.Output != nil {
+			resultBytes, err = generator.ConvertToYAML(result, *cfg.Output)
+		} else {
+			resultBytes, err = generator.ConvertToBytes(result)
+		}
 		if err != nil {
 			return err
 		}
@@ -62,5 +78,8 @@ var generateCmd = &cobra.Command{
 func init() {
 	generateCmd.Flags().StringToStringVar(&genFlags.vars, "vars", nil, "Provide vars to the pipeline")
 	generateCmd.Flags().BoolVar(&genFlags.debug, "debug", false, "If true, log each stage as it executes")
+	generateCmd.Flags().BoolVar(&genFlags.sort, "sort", false, "If true, sort the output Kubernetes resources into the order Helm installs them in")
+	generateCmd.Flags().StringVar(&genFlags.cacheDir, "cache-dir", "", "Directory to cache remote inputs in, defaults to a yamlforge directory in the user cache directory")
+	generateCmd.Flags().BoolVar(&genFlags.offline, "offline", false, "If true, remote inputs are only read from the cache")
 	RootCmd.AddCommand(generateCmd)
 }
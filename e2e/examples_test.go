@@ -2,6 +2,10 @@ package e2e
 
 import (
 	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
 	"path"
 	"strings"
 	"testing"
@@ -119,6 +123,580 @@ spec:
           targetPort: 9999
     selector:
         app.kubernetes.io/name: MyApp
+`),
+		},
+		{
+			file: "merge-streams.yfg.yaml",
+			expected: trim(`
+apiVersion: v1
+kind: Service
+metadata:
+    annotations:
+        service.beta.kubernetes.io/aws-load-balancer-type: nlb
+    name: my-service
+spec:
+    ports:
+        - name: grpc
+          port: 80
+          targetPort: 9376
+    selector:
+        app.kubernetes.io/name: MyApp
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    name: my-app
+spec:
+    replicas: 3
+`),
+		},
+		{
+			file: "k8s.yfg.yaml",
+			expected: trim(`
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+    annotations:
+        example.com/owner: platform-team
+    labels:
+        app.kubernetes.io/part-of: my-system
+    name: prod-my-app
+    namespace: production
+---
+apiVersion: v1
+data:
+    LOG_LEVEL: info
+kind: ConfigMap
+metadata:
+    annotations:
+        example.com/owner: platform-team
+    labels:
+        app.kubernetes.io/part-of: my-system
+    name: prod-my-app-config
+    namespace: production
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    annotations:
+        example.com/owner: platform-team
+    labels:
+        app.kubernetes.io/part-of: my-system
+    name: prod-my-app
+    namespace: production
+spec:
+    selector:
+        matchLabels:
+            app.kubernetes.io/name: my-app
+    template:
+        metadata:
+            annotations:
+                example.com/owner: platform-team
+            labels:
+                app.kubernetes.io/name: my-app
+                app.kubernetes.io/part-of: my-system
+        spec:
+            containers:
+                - envFrom:
+                      - configMapRef:
+                            name: prod-my-app-config
+                  image: ghcr.io/example/my-app:v1.1.0
+                  name: app
+            serviceAccountName: prod-my-app
+`),
+		},
+		{
+			file: "configmap.yfg.yaml",
+			expected: trim(`
+apiVersion: v1
+data:
+    LOG_LEVEL: debug
+    app.properties: |
+        server.port=8080
+        server.host=0.0.0.0
+    logging.properties: |
+        log.level=info
+kind: ConfigMap
+metadata:
+    name: my-app-config-1987d5a29c
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    name: my-app
+spec:
+    selector:
+        matchLabels:
+            app.kubernetes.io/name: my-app
+    template:
+        metadata:
+            labels:
+                app.kubernetes.io/name: my-app
+        spec:
+            containers:
+                - image: ghcr.io/example/my-app:v1.0.0
+                  name: app
+                  volumeMounts:
+                      - mountPath: /etc/my-app
+                        name: config
+            volumes:
+                - configMap:
+                      name: my-app-config-1987d5a29c
+                  name: config
+`),
+		},
+		{
+			file: "sort.yfg.yaml",
+			expected: trim(`
+apiVersion: v1
+kind: Namespace
+metadata:
+    name: production
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+    name: my-app
+---
+apiVersion: v1
+data:
+    LOG_LEVEL: info
+kind: ConfigMap
+metadata:
+    name: my-app-config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    name: my-app
+spec:
+    selector:
+        matchLabels:
+            app.kubernetes.io/name: my-app
+    template:
+        metadata:
+            labels:
+                app.kubernetes.io/name: my-app
+        spec:
+            containers:
+                - envFrom:
+                      - configMapRef:
+                            name: my-app-config
+                  image: ghcr.io/example/my-app:v1.0.0
+                  name: app
+            serviceAccountName: my-app
+`),
+		},
+		{
+			file: "validate.yfg.yaml",
+			expected: trim(`
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+    name: my-app
+---
+apiVersion: v1
+data:
+    LOG_LEVEL: info
+kind: ConfigMap
+metadata:
+    name: my-app-config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    name: my-app
+spec:
+    selector:
+        matchLabels:
+            app.kubernetes.io/name: my-app
+    template:
+        metadata:
+            labels:
+                app.kubernetes.io/name: my-app
+        spec:
+            containers:
+                - envFrom:
+                      - configMapRef:
+                            name: my-app-config
+                  image: ghcr.io/example/my-app:v1.0.0
+                  name: app
+            serviceAccountName: my-app
+---
+apiVersion: example.com/v1
+kind: Database
+metadata:
+    name: my-app-db
+spec:
+    backupSchedule: null
+    engine: postgres
+    storageGB: 20
+`),
+		},
+		{
+			file: "jsonschema.yfg.yaml",
+			expected: trim(`
+image:
+    repository: ghcr.io/example/my-app
+    tag: v1.0.0
+replicaCount: 2
+`),
+		},
+		{
+			file: "assert.yfg.yaml",
+			expected: trim(`
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+    name: my-app
+---
+apiVersion: v1
+data:
+    LOG_LEVEL: info
+kind: ConfigMap
+metadata:
+    name: my-app-config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    name: my-app
+spec:
+    selector:
+        matchLabels:
+            app.kubernetes.io/name: my-app
+    template:
+        metadata:
+            labels:
+                app.kubernetes.io/name: my-app
+        spec:
+            containers:
+                - envFrom:
+                      - configMapRef:
+                            name: my-app-config
+                  image: ghcr.io/example/my-app:v1.0.0
+                  name: app
+            serviceAccountName: my-app
+`),
+		},
+		{
+			file: "cel-refs.yfg.yaml",
+			expected: trim(`
+encodedName: bXktYXBw
+image: ghcr.io/example/my-app:v1.0.0
+kinds: true
+maxReplicas: 3
+memoryBytes: 536870912
+names: my-app,my-app-config
+podCIDR: true
+trustedRegistry: true
+upToDate: true
+`),
+		},
+		{
+			file: "cel-stream.yfg.yaml",
+			expected: trim(`
+containers:
+    - app
+images:
+    image: ghcr.io/example/my-app:v1.0.0
+    resource: my-app
+kinds:
+    - ConfigMap
+    - Deployment
+    - ServiceAccount
+serviceAccounts:
+    - my-app
+`),
+		},
+		{
+			file: "interpolation.yfg.yaml",
+			expected: trim(`
+apiVersion: v1
+data:
+    app.properties: |
+        server.port=8080
+        server.host=0.0.0.0
+    args: |
+        2 ["us-east-1a","us-east-1b"] ${{ not interpolated }}
+    workflow: "run: deploy prod ${{ github.sha }}"
+kind: ConfigMap
+metadata:
+    name: prod-us-east-1
+    namespace: team-us
+`),
+		},
+		{
+			file: "template-partials.yfg.yaml",
+			expected: trim(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    annotations:
+        description: my-app running v1.2.3
+    labels:
+        app.kubernetes.io/name: my-app
+        app.kubernetes.io/version: v1.2.3
+    name: my-app-production
+spec:
+    replicas: 2
+    template:
+        spec:
+            containers:
+                - name: app
+                  resources:
+                      limits:
+                          memory: 512Mi
+`),
+		},
+		{
+			file: "template-per-document.yfg.yaml",
+			expected: trim(`
+alert: HighErrorRate
+annotations:
+    summary: HighErrorRate on {{ $labels.instance }}
+expr: rate(http_errors_total[5m]) > 1
+---
+alert: InstanceDown
+annotations:
+    summary: InstanceDown on {{ $labels.instance }}
+expr: up == 0
+`),
+		},
+		{
+			file: "starlark.yfg.yaml",
+			expected: trim(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    labels:
+        app.kubernetes.io/name: my-app
+        environment: staging
+    name: my-app-staging
+spec:
+    replicas: 1
+    selector:
+        matchLabels:
+            app.kubernetes.io/name: my-app
+            environment: staging
+    template:
+        metadata:
+            labels:
+                app.kubernetes.io/name: my-app
+                environment: staging
+        spec:
+            containers:
+                - image: ghcr.io/example/my-app:v1.0.0
+                  name: app
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    labels:
+        app.kubernetes.io/name: my-app
+        environment: production
+    name: my-app-production
+spec:
+    replicas: 3
+    selector:
+        matchLabels:
+            app.kubernetes.io/name: my-app
+            environment: production
+    template:
+        metadata:
+            labels:
+                app.kubernetes.io/name: my-app
+                environment: production
+        spec:
+            containers:
+                - image: ghcr.io/example/my-app:v1.0.0
+                  name: app
+`),
+		},
+		{
+			file: "jsonnet.yfg.yaml",
+			expected: trim(`
+apiVersion: v1
+kind: Service
+metadata:
+    labels:
+        app.kubernetes.io/name: my-api
+    name: my-api
+spec:
+    ports:
+        - port: 8080
+          targetPort: 8080
+    selector:
+        app.kubernetes.io/name: my-api
+---
+apiVersion: v1
+kind: Service
+metadata:
+    labels:
+        app.kubernetes.io/name: my-web
+    name: my-web
+spec:
+    ports:
+        - port: 80
+          targetPort: 80
+    selector:
+        app.kubernetes.io/name: my-web
+`),
+		},
+		{
+			file: "cue.yfg.yaml",
+			expected: trim(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    labels:
+        app.kubernetes.io/name: my-app
+    name: my-app
+spec:
+    replicas: 3
+    selector:
+        matchLabels:
+            app.kubernetes.io/name: my-app
+    template:
+        metadata:
+            labels:
+                app.kubernetes.io/name: my-app
+        spec:
+            containers:
+                - image: ghcr.io/example/my-app:v1.0.0
+                  name: app
+                  ports:
+                      - containerPort: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+    labels:
+        app.kubernetes.io/name: my-app
+    name: my-app
+spec:
+    ports:
+        - port: 80
+          targetPort: 8080
+    selector:
+        app.kubernetes.io/name: my-app
+`),
+		},
+		{
+			file: "formats.yfg.yaml",
+			expected: trim(`
+LOG_LEVEL = debug
+SERVER_PORT = 9090
+database.url = postgres://db:5432/app
+logging.level = info
+server.host = 0.0.0.0
+server.port = 8080
+title = my-app
+`),
+		},
+		{
+			file: "encode.yfg.yaml",
+			expected: trim(`
+{"database":{"url":"postgres://db:5432/app"},"server":{"host":"0.0.0.0","port":8080},"title":"my-app"}
+`),
+		},
+		{
+			file: "preserve.yfg.yaml",
+			expected: trim(`
+# Default values for my-app.
+replicaCount: 3 # scaled by the HPA in production
+
+image:
+  repository: ghcr.io/example/my-app
+  # Overridden by CI.
+  tag: v1.1.0
+
+defaults: &resources
+  cpu: 100m
+  memory: 128Mi
+
+resources:
+  requests: *resources
+  limits:
+    <<: *resources
+    memory: 256Mi
+
+ingress:
+  enabled: true
+  hosts:
+    - my-app.example.com
+    - my-app.example.org
+`),
+		},
+		{
+			file: "output-format.yfg.yaml",
+			expected: trim(`
+---
+description: >-
+  A description of the application which is much too long to fit on a single
+  line without being folded.
+hosts:
+  - a.example.com
+  - b.example.com
+  - c.example.com
+  - d.example.com
+name: my-app
+owner: ~
+ports: [80, 443]
+version: '1.0'
+`),
+		},
+		{
+			file: "json-lines.yfg.yaml",
+			expected: trim(`
+{"duration":1.5,"id":2,"message":"Deployed <my-app> & restarted"}
+{"duration":10,"id":1,"message":"Scaled to 3 replicas"}
+`),
+		},
+		{
+			file: "path.yfg.yaml",
+			expected: trim(`
+apiVersion: v1
+data:
+    configMap: my-app-config
+    image: ghcr.io/example/my-app:v1.0.0
+    replicas: "1"
+kind: ConfigMap
+metadata:
+    name: my-app
+`),
+		},
+		{
+			file: "files.yfg.yaml",
+			expected: trim(`
+title: Requests
+type: timeseries
+---
+title: Connections
+type: stat
+---
+apiVersion: v1
+data:
+    app.json: "{\n  \"title\": \"My App\",\n  \"panels\": [\n    {\"type\": \"timeseries\", \"title\": \"Requests\"}\n  ]\n}\n"
+    database.json: "{\n  \"title\": \"Database\",\n  \"panels\": [\n    {\"type\": \"stat\", \"title\": \"Connections\"}\n  ]\n}\n"
+kind: ConfigMap
+metadata:
+    labels:
+        grafana_dashboard: "1"
+    name: grafana-dashboards
+`),
+		},
+		{
+			file: "secrets.yfg.yaml",
+			expected: trim(`
+apiVersion: v1
+data:
+    DB_PASSWORD: Y29ycmVjdC1ob3JzZS1iYXR0ZXJ5LXN0YXBsZQ==
+    DB_USERNAME: YXBw
+kind: Secret
+metadata:
+    name: database
+type: Opaque
 `),
 		},
 		{
@@ -187,3 +765,70 @@ spec:
 		})
 	}
 }
+
+func TestHTTPExample(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("../examples/files")))
+	defer server.Close()
+	cacheDir := t.TempDir()
+	t.Cleanup(func() {
+		// Flags persist between executions of the command.
+		generateCmd, _, err := cmd.RootCmd.Find([]string{"generate"})
+		require.NoError(t, err)
+		require.NoError(t, generateCmd.Flags().Set("offline", "false"))
+		require.NoError(t, generateCmd.Flags().Set("cache-dir", ""))
+	})
+
+	expected := `repository: ghcr.io/example/my-app
+tag: v1.0.0
+`
+	generate := func(args ...string) (string, error) {
+		var buf bytes.Buffer
+		c := cmd.RootCmd
+		c.SetArgs(append([]string{"generate", "--cache-dir", cacheDir}, append(args, "../examples/http.yfg.yaml")...))
+		c.SetOut(&buf)
+		err := c.Execute()
+		return buf.String(), err
+	}
+	out, err := generate("--vars", "url="+server.URL+"/values.yaml")
+	require.NoError(t, err)
+	require.Equal(t, expected, out)
+
+	// The response is read from the cache when offline.
+	server.Close()
+	out, err = generate("--offline", "--vars", "url="+server.URL+"/values.yaml")
+	require.NoError(t, err)
+	require.Equal(t, expected, out)
+}
+
+func TestGitExample(t *testing.T) {
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		c := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		out, err := c.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	writeValues := func(tag string) {
+		t.Helper()
+		p := path.Join(repo, "examples/files/values.yaml")
+		require.NoError(t, os.MkdirAll(path.Dir(p), 0750))
+		require.NoError(t, os.WriteFile(p, []byte("image:\n  repository: ghcr.io/example/my-app\n  tag: "+tag+"\n"), 0640))
+		git("add", "--all")
+		git("commit", "--quiet", "--message", "Release "+tag)
+	}
+	git("init", "--quiet")
+	git("config", "user.name", "test")
+	git("config", "user.email", "test@example.com")
+	git("config", "commit.gpgsign", "false")
+	writeValues("v1.0.0")
+	writeValues("v2.0.0")
+
+	var buf bytes.Buffer
+	c := cmd.RootCmd
+	c.SetArgs([]string{"generate", "--vars", "repo=" + repo, "--vars", "ref=HEAD~1", "../examples/git.yfg.yaml"})
+	c.SetOut(&buf)
+	require.NoError(t, c.Execute())
+	require.Equal(t, `repository: ghcr.io/example/my-app
+tag: v1.0.0
+`, buf.String())
+}
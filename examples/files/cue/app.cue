@@ -0,0 +1,44 @@
+package app
+
+#Values: {
+	name:     string & =~"^[a-z][a-z0-9-]*$"
+	image:    string
+	replicas: int & >=1 & <=10 | *1
+	port:     int | *8080
+}
+
+values: #Values
+
+_labels: "app.kubernetes.io/name": values.name
+
+objects: [{
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	metadata: {
+		name:   values.name
+		labels: _labels
+	}
+	spec: {
+		replicas: values.replicas
+		selector: matchLabels: _labels
+		template: {
+			metadata: labels: _labels
+			spec: containers: [{
+				name:  "app"
+				image: values.image
+				ports: [{containerPort: values.port}]
+			}]
+		}
+	}
+}, {
+	apiVersion: "v1"
+	kind:       "Service"
+	metadata: {
+		name:   values.name
+		labels: _labels
+	}
+	spec: {
+		selector: _labels
+		ports: [{port: 80, targetPort: values.port}]
+	}
+}]
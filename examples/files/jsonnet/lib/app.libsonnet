@@ -0,0 +1,15 @@
+{
+  labels(name):: {
+    'app.kubernetes.io/name': name,
+  },
+
+  service(name, port):: {
+    apiVersion: 'v1',
+    kind: 'Service',
+    metadata: { name: name, labels: $.labels(name) },
+    spec: {
+      selector: $.labels(name),
+      ports: [{ port: port, targetPort: port }],
+    },
+  },
+}
@@ -0,0 +1,8 @@
+{{- define "labels" -}}
+app.kubernetes.io/name: {{ .name }}
+app.kubernetes.io/version: {{ .version }}
+{{- end -}}
+
+{{- define "fullname" -}}
+{{ .name }}-{{ .environment }}
+{{- end -}}
@@ -0,0 +1,34 @@
+// Package cellib configures the CEL environment used to evaluate expressions
+// in pipelines.
+package cellib
+
+import (
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+// NewEnv returns a CEL environment with the extension libraries and yamlforge
+// helpers for quantities, semantic versions, URLs and IPs available to all
+// expressions, and the variables 'vars' and 'refs' declared.
+// opts are applied after the default options.
+func NewEnv(opts ...cel.EnvOption) (*cel.Env, error) {
+	envOpts := []cel.EnvOption{
+		cel.OptionalTypes(),
+		ext.Strings(),
+		ext.Lists(),
+		ext.Sets(),
+		ext.Math(),
+		ext.Encoders(),
+		ext.Regex(),
+		ext.Bindings(),
+		quantityLib(),
+		semverLib(),
+		urlLib(),
+		ipLib(),
+		// vars contains the pipeline variables.
+		cel.Variable("vars", cel.MapType(cel.StringType, cel.DynType)),
+		// refs contains the output of each previously executed stage by name.
+		cel.Variable("refs", cel.MapType(cel.StringType, cel.DynType)),
+	}
+	return cel.NewEnv(append(envOpts, opts...)...)
+}
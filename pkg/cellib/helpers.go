@@ -0,0 +1,250 @@
+package cellib
+
+// The helpers in this file are specific to yamlforge, and aren't compatible
+// with the Kubernetes CEL libraries, which return opaque types such as
+// Quantity and Semver rather than plain values. They're namespaced to avoid
+// being mistaken for the Kubernetes functions of the same name.
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// stringFunc declares a function with a single string argument.
+func stringFunc(name string, result *cel.Type, fn func(s string) ref.Val) cel.EnvOption {
+	return cel.Function(name,
+		cel.Overload(name+"_string", []*cel.Type{cel.StringType}, result,
+			cel.UnaryBinding(func(arg ref.Val) ref.Val {
+				return fn(string(arg.(types.String)))
+			}),
+		),
+	)
+}
+
+// stringPairFunc declares a function with two string arguments.
+func stringPairFunc(name string, result *cel.Type, fn func(a, b string) ref.Val) cel.EnvOption {
+	return cel.Function(name,
+		cel.Overload(name+"_string_string", []*cel.Type{cel.StringType, cel.StringType}, result,
+			cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+				return fn(string(lhs.(types.String)), string(rhs.(types.String)))
+			}),
+		),
+	)
+}
+
+func mapValue(m map[string]any) ref.Val {
+	return types.DefaultTypeAdapter.NativeToValue(m)
+}
+
+var quantityRegexp = regexp.MustCompile(`^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$`)
+
+var quantitySuffixes = map[string]*big.Float{
+	"n":  big.NewFloat(1e-9),
+	"u":  big.NewFloat(1e-6),
+	"m":  big.NewFloat(1e-3),
+	"":   big.NewFloat(1),
+	"k":  big.NewFloat(1e3),
+	"M":  big.NewFloat(1e6),
+	"G":  big.NewFloat(1e9),
+	"T":  big.NewFloat(1e12),
+	"P":  big.NewFloat(1e15),
+	"E":  big.NewFloat(1e18),
+	"Ki": big.NewFloat(1 << 10),
+	"Mi": big.NewFloat(1 << 20),
+	"Gi": big.NewFloat(1 << 30),
+	"Ti": big.NewFloat(1 << 40),
+	"Pi": big.NewFloat(1 << 50),
+	"Ei": big.NewFloat(1 << 60),
+}
+
+// parseQuantity parses a Kubernetes resource quantity such as 500m or 1Gi.
+func parseQuantity(s string) (float64, error) {
+	parts := quantityRegexp.FindStringSubmatch(s)
+	if parts == nil {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	num, ok := new(big.Float).SetString(parts[1])
+	if !ok {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	suffix := parts[2]
+	if multiplier, ok := quantitySuffixes[suffix]; ok {
+		num.Mul(num, multiplier)
+	} else if len(suffix) > 1 && (suffix[0] == 'e' || suffix[0] == 'E') {
+		exp, err := strconv.Atoi(suffix[1:])
+		if err != nil {
+			return 0, fmt.Errorf("invalid quantity %q", s)
+		}
+		num.Mul(num, big.NewFloat(math.Pow10(exp)))
+	} else {
+		return 0, fmt.Errorf("invalid quantity %q: unknown suffix %q", s, suffix)
+	}
+	f, _ := num.Float64()
+	return f, nil
+}
+
+// quantityLib provides functions for Kubernetes resource quantities:
+//
+//	quantity.toDouble('500m') == 0.5
+//	quantity.isValid('1Gi') == true
+func quantityLib() cel.EnvOption {
+	return cel.Lib(library{
+		stringFunc("quantity.toDouble", cel.DoubleType, func(s string) ref.Val {
+			f, err := parseQuantity(s)
+			if err != nil {
+				return types.NewErrFromString(err.Error())
+			}
+			return types.Double(f)
+		}),
+		stringFunc("quantity.isValid", cel.BoolType, func(s string) ref.Val {
+			_, err := parseQuantity(s)
+			return types.Bool(err == nil)
+		}),
+	})
+}
+
+// semverLib provides functions for semantic versions:
+//
+//	semver.parse('v1.2.3-rc.1').minor == 2
+//	semver.isValid('1.2.3') == true
+//	semver.compare('1.2.3', '1.10.0') == -1
+//	semver.matches('1.2.3', '>= 1.2, < 2') == true
+func semverLib() cel.EnvOption {
+	return cel.Lib(library{
+		stringFunc("semver.parse", cel.MapType(cel.StringType, cel.DynType), func(s string) ref.Val {
+			v, err := semver.NewVersion(s)
+			if err != nil {
+				return types.NewErrFromString(fmt.Sprintf("invalid semver %q: %s", s, err))
+			}
+			return mapValue(map[string]any{
+				"major":      int64(v.Major()),
+				"minor":      int64(v.Minor()),
+				"patch":      int64(v.Patch()),
+				"prerelease": v.Prerelease(),
+				"metadata":   v.Metadata(),
+			})
+		}),
+		stringFunc("semver.isValid", cel.BoolType, func(s string) ref.Val {
+			_, err := semver.NewVersion(s)
+			return types.Bool(err == nil)
+		}),
+		stringPairFunc("semver.compare", cel.IntType, func(a, b string) ref.Val {
+			va, err := semver.NewVersion(a)
+			if err != nil {
+				return types.NewErrFromString(fmt.Sprintf("invalid semver %q: %s", a, err))
+			}
+			vb, err := semver.NewVersion(b)
+			if err != nil {
+				return types.NewErrFromString(fmt.Sprintf("invalid semver %q: %s", b, err))
+			}
+			return types.Int(va.Compare(vb))
+		}),
+		stringPairFunc("semver.matches", cel.BoolType, func(version, constraint string) ref.Val {
+			v, err := semver.NewVersion(version)
+			if err != nil {
+				return types.NewErrFromString(fmt.Sprintf("invalid semver %q: %s", version, err))
+			}
+			c, err := semver.NewConstraint(constraint)
+			if err != nil {
+				return types.NewErrFromString(fmt.Sprintf("invalid semver constraint %q: %s", constraint, err))
+			}
+			return types.Bool(c.Check(v))
+		}),
+	})
+}
+
+// urlLib provides functions for URLs:
+//
+//	url.parse('https://example.com:8443/path?a=b').port == '8443'
+//	url.isValid('https://example.com') == true
+func urlLib() cel.EnvOption {
+	return cel.Lib(library{
+		stringFunc("url.parse", cel.MapType(cel.StringType, cel.DynType), func(s string) ref.Val {
+			u, err := url.Parse(s)
+			if err != nil {
+				return types.NewErrFromString(fmt.Sprintf("invalid URL %q: %s", s, err))
+			}
+			query := make(map[string]any)
+			for key, vals := range u.Query() {
+				query[key] = vals
+			}
+			return mapValue(map[string]any{
+				"scheme":   u.Scheme,
+				"host":     u.Host,
+				"hostname": u.Hostname(),
+				"port":     u.Port(),
+				"path":     u.Path,
+				"query":    query,
+				"fragment": u.Fragment,
+			})
+		}),
+		stringFunc("url.isValid", cel.BoolType, func(s string) ref.Val {
+			u, err := url.Parse(s)
+			return types.Bool(err == nil && u.Scheme != "" && u.Host != "")
+		}),
+	})
+}
+
+// ipLib provides functions for IP addresses and CIDRs:
+//
+//	ip.isValid('10.0.0.1') == true
+//	ip.family('fd00::1') == 6
+//	cidr.isValid('10.0.0.0/8') == true
+//	cidr.contains('10.0.0.0/8', '10.1.2.3') == true
+func ipLib() cel.EnvOption {
+	return cel.Lib(library{
+		stringFunc("ip.isValid", cel.BoolType, func(s string) ref.Val {
+			_, err := netip.ParseAddr(s)
+			return types.Bool(err == nil)
+		}),
+		stringFunc("cidr.isValid", cel.BoolType, func(s string) ref.Val {
+			_, err := netip.ParsePrefix(s)
+			return types.Bool(err == nil)
+		}),
+		stringFunc("ip.family", cel.IntType, func(s string) ref.Val {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return types.NewErrFromString(fmt.Sprintf("invalid IP %q: %s", s, err))
+			}
+			if addr.Is4() {
+				return types.Int(4)
+			}
+			return types.Int(6)
+		}),
+		stringPairFunc("cidr.contains", cel.BoolType, func(cidr, ipOrCIDR string) ref.Val {
+			prefix, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				return types.NewErrFromString(fmt.Sprintf("invalid CIDR %q: %s", cidr, err))
+			}
+			if addr, err := netip.ParseAddr(ipOrCIDR); err == nil {
+				return types.Bool(prefix.Contains(addr))
+			}
+			other, err := netip.ParsePrefix(ipOrCIDR)
+			if err != nil {
+				return types.NewErrFromString(fmt.Sprintf("invalid IP or CIDR %q: %s", ipOrCIDR, err))
+			}
+			return types.Bool(other.Bits() >= prefix.Bits() && prefix.Contains(other.Addr()))
+		}),
+	})
+}
+
+// library is a cel.Library composed of environment options.
+type library []cel.EnvOption
+
+func (l library) CompileOptions() []cel.EnvOption {
+	return l
+}
+
+func (l library) ProgramOptions() []cel.ProgramOption {
+	return nil
+}
@@ -0,0 +1,53 @@
+package cellib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelpers(t *testing.T) {
+	env, err := NewEnv()
+	require.NoError(t, err)
+
+	tests := []struct {
+		expr     string
+		expected any
+	}{
+		{expr: `quantity.toDouble("500m")`, expected: 0.5},
+		{expr: `quantity.toDouble("1Gi")`, expected: float64(1 << 30)},
+		{expr: `quantity.toDouble("2k")`, expected: 2000.0},
+		{expr: `quantity.toDouble("1e3")`, expected: 1000.0},
+		{expr: `quantity.isValid("1Gb")`, expected: false},
+		{expr: `semver.parse("v1.2.3-rc.1").minor`, expected: int64(2)},
+		{expr: `semver.parse("v1.2.3-rc.1").prerelease`, expected: "rc.1"},
+		{expr: `semver.isValid("latest")`, expected: false},
+		{expr: `semver.compare("1.2.3", "1.10.0")`, expected: int64(-1)},
+		{expr: `semver.matches("1.2.3", ">= 1.2, < 2")`, expected: true},
+		{expr: `url.parse("https://example.com:8443/path?a=b").port`, expected: "8443"},
+		{expr: `url.parse("https://example.com/path?a=b").query.a[0]`, expected: "b"},
+		{expr: `url.isValid("example.com")`, expected: false},
+		{expr: `ip.isValid("10.0.0.1")`, expected: true},
+		{expr: `cidr.isValid("10.0.0.1")`, expected: false},
+		{expr: `ip.family("fd00::1")`, expected: int64(6)},
+		{expr: `cidr.contains("10.0.0.0/8", "10.1.2.3")`, expected: true},
+		{expr: `cidr.contains("10.0.0.0/8", "10.1.0.0/16")`, expected: true},
+		{expr: `cidr.contains("10.1.0.0/16", "10.0.0.0/8")`, expected: false},
+		{expr: `vars.name.upperAscii()`, expected: "APP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			ast, iss := env.Compile(tt.expr)
+			require.NoError(t, iss.Err())
+			prg, err := env.Program(ast)
+			require.NoError(t, err)
+			out, _, err := prg.Eval(map[string]any{
+				"vars": map[string]any{"name": "app"},
+				"refs": map[string]any{},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out.Value())
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package cellib
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// ToNative converts a CEL value into the Go values produced when decoding
+// YAML or JSON.
+func ToNative(val ref.Val) any {
+	switch v := val.(type) {
+	case types.Null:
+		return nil
+	case traits.Mapper:
+		ret := make(map[string]any)
+		it := v.Iterator()
+		for it.HasNext() == types.True {
+			key := it.Next()
+			ret[fmt.Sprint(key.Value())] = ToNative(v.Get(key))
+		}
+		return ret
+	case traits.Lister:
+		var ret []any
+		it := v.Iterator()
+		for it.HasNext() == types.True {
+			ret = append(ret, ToNative(it.Next()))
+		}
+		if ret == nil {
+			ret = []any{}
+		}
+		return ret
+	default:
+		return v.Value()
+	}
+}
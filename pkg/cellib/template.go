@@ -0,0 +1,159 @@
+package cellib
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+)
+
+// Template is a string containing CEL expressions delimited by ${{ and }}.
+// Each expression is replaced with its result when the template is executed.
+// A literal ${{ is written as $${{.
+type Template struct {
+	parts []templatePart
+}
+
+type templatePart struct {
+	text   string
+	isExpr bool
+}
+
+// ParseTemplate parses s into a template. Strings without expressions are
+// returned unchanged when executed, except for unescaping $${{.
+func ParseTemplate(s string) (*Template, error) {
+	t := &Template{}
+	var literal strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "$${{"):
+			literal.WriteString("${{")
+			i += len("$${{")
+		case strings.HasPrefix(s[i:], "${{"):
+			start := i + len("${{")
+			end := findExpressionEnd(s, start)
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated expression at offset %d, missing }}", i)
+			}
+			expr := strings.TrimSpace(s[start:end])
+			if expr == "" {
+				return nil, fmt.Errorf("empty expression at offset %d", i)
+			}
+			if literal.Len() != 0 {
+				t.parts = append(t.parts, templatePart{text: literal.String()})
+				literal.Reset()
+			}
+			t.parts = append(t.parts, templatePart{text: expr, isExpr: true})
+			i = end + len("}}")
+		default:
+			literal.WriteByte(s[i])
+			i++
+		}
+	}
+	if literal.Len() != 0 {
+		t.parts = append(t.parts, templatePart{text: literal.String()})
+	}
+	return t, nil
+}
+
+// findExpressionEnd returns the offset of the }} ending the expression starting
+// at start, skipping braces within map literals and string literals.
+func findExpressionEnd(s string, start int) int {
+	depth := 0
+	var quote byte
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			switch c {
+			case '\\':
+				i++
+			case quote:
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 && strings.HasPrefix(s[i:], "}}") {
+				return i
+			}
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	return -1
+}
+
+// HasExpressions returns true if the template contains any expressions.
+func (t *Template) HasExpressions() bool {
+	for _, part := range t.parts {
+		if part.isExpr {
+			return true
+		}
+	}
+	return false
+}
+
+// Check compiles and type checks each expression in the template.
+func (t *Template) Check(env *cel.Env) error {
+	for _, part := range t.parts {
+		if !part.isExpr {
+			continue
+		}
+		if _, err := compile(env, part.text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Execute evaluates each expression with the variables in activation and
+// returns the resulting string. Strings are inserted as is, and other values
+// are inserted as JSON.
+func (t *Template) Execute(env *cel.Env, activation any) (string, error) {
+	var sb strings.Builder
+	for _, part := range t.parts {
+		if !part.isExpr {
+			sb.WriteString(part.text)
+			continue
+		}
+		prg, err := compile(env, part.text)
+		if err != nil {
+			return "", err
+		}
+		out, _, err := prg.Eval(activation)
+		if err != nil {
+			return "", fmt.Errorf("error evaluating expression %q: %s", part.text, err)
+		}
+		switch v := ToNative(out).(type) {
+		case string:
+			sb.WriteString(v)
+		case nil:
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return "", fmt.Errorf("error converting result of expression %q to a string: %w", part.text, err)
+			}
+			sb.Write(data)
+		}
+	}
+	return sb.String(), nil
+}
+
+func compile(env *cel.Env, expr string) (cel.Program, error) {
+	ast, iss := env.Compile(expr)
+	if iss != nil && iss.Err() != nil {
+		return nil, fmt.Errorf("error compiling expression %q: %s", expr, iss.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("error creating program for expression %q: %s", expr, err)
+	}
+	return prg, nil
+}
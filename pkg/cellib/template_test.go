@@ -0,0 +1,50 @@
+package cellib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate(t *testing.T) {
+	env, err := NewEnv()
+	require.NoError(t, err)
+	activation := map[string]any{
+		"vars": map[string]any{"env": "prod", "region": "us-east-1"},
+		"refs": map[string]any{"values": map[string]any{"replicas": int64(3)}},
+	}
+
+	tests := []struct {
+		tmpl     string
+		expected string
+	}{
+		{tmpl: "no expressions", expected: "no expressions"},
+		{tmpl: "${{ vars.env }}-${{vars.region}}", expected: "prod-us-east-1"},
+		{tmpl: "replicas=${{ refs.values.replicas + 1 }}", expected: "replicas=4"},
+		{tmpl: `${{ {"a": {"b": "}}"}} }}`, expected: `{"a":{"b":"}}"}}`},
+		{tmpl: "${{ [1, 2] }}", expected: "[1,2]"},
+		{tmpl: "${{ null }}", expected: ""},
+		{tmpl: "$${{ vars.env }}", expected: "${{ vars.env }}"},
+		{tmpl: "$$${{ vars.env }}", expected: "$${{ vars.env }}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.tmpl)
+			require.NoError(t, err)
+			require.NoError(t, tmpl.Check(env))
+			out, err := tmpl.Execute(env, activation)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out)
+		})
+	}
+
+	_, err = ParseTemplate("${{ vars.env")
+	assert.ErrorContains(t, err, "unterminated expression")
+	_, err = ParseTemplate("${{ }}")
+	assert.ErrorContains(t, err, "empty expression")
+
+	tmpl, err := ParseTemplate("${{ vars.env + }}")
+	require.NoError(t, err)
+	assert.ErrorContains(t, tmpl.Check(env), "error compiling expression")
+}
@@ -3,6 +3,8 @@ package config
 // Config defines a yamlforge configuration.
 type Config struct {
 	PipelineGenerator `yaml:",inline" json:",inline"`
+	// Output configures how the output of the pipeline is formatted when it is YAML.
+	Output *YAMLFormat `yaml:"output,omitempty" json:"output,omitempty"`
 }
 
 // Generators execute some logic and produce output.
@@ -10,6 +12,9 @@ type Config struct {
 type Generator struct {
 	// Name is the name of this generator which other generators can reference this generator's output by.
 	Name string `yaml:"name" json:"name"`
+	// Interpolate enables ${{ }} CEL expressions in the string fields of this generator, such as names, arguments and file paths, which are evaluated with the pipeline variables as 'vars' and the output of previous stages as 'refs'. Use $${{ to include a literal ${{.
+	// Templates, expressions and data, such as the template of a gotemplate generator, the expression and input of a jq generator and the value of a value generator, are not interpolated, but the file, glob and dir paths of their values are. Nested generators are only interpolated if they enable interpolation themselves.
+	Interpolate bool `yaml:"interpolate,omitempty" json:"interpolate,omitempty"`
 	// Value is a simple generator that takes a value and returns it unaltered.
 	Value *AnyOrValue `yaml:"value,omitempty" json:"value,omitempty" jsonschema:"oneof_required=value"`
 	// File is a generator which reads files at the specified path and returns their output.
@@ -38,12 +43,50 @@ type Generator struct {
 	YAML *YAMLGenerator `yaml:"yaml,omitempty" json:"yaml,omitempty" jsonschema:"oneof_required=yaml"`
 	// JSON is a generator which returns it's inputs as JSON.
 	JSON *JSONGenerator `yaml:"json,omitempty" json:"json,omitempty" jsonschema:"oneof_required=json"`
+	// Kubernetes is a generator which applies common transformations, such as setting the namespace or adding labels, to a stream of Kubernetes resources.
+	Kubernetes *KubernetesGenerator `yaml:"k8s,omitempty" json:"k8s,omitempty" jsonschema:"oneof_required=k8s"`
+	// ConfigMap is a generator which builds a Kubernetes ConfigMap from its inputs, with a hash of its content appended to its name.
+	ConfigMap *ConfigMapGenerator `yaml:"configMap,omitempty" json:"configMap,omitempty" jsonschema:"oneof_required=configMap"`
+	// Secret is a generator which builds a Kubernetes Secret from its inputs, with a hash of its content appended to its name.
+	Secret *SecretGenerator `yaml:"secret,omitempty" json:"secret,omitempty" jsonschema:"oneof_required=secret"`
+	// Sort is a generator which sorts a stream of Kubernetes resources into the order they should be applied in.
+	Sort *SortGenerator `yaml:"sort,omitempty" json:"sort,omitempty" jsonschema:"oneof_required=sort"`
+	// Validate is a generator which validates Kubernetes resources against their OpenAPI schemas and returns them unaltered.
+	Validate *ValidateGenerator `yaml:"validate,omitempty" json:"validate,omitempty" jsonschema:"oneof_required=validate"`
+	// JSONSchema is a generator which validates its input against a JSON schema and returns the input unaltered.
+	JSONSchema *JSONSchemaGenerator `yaml:"jsonschema,omitempty" json:"jsonschema,omitempty" jsonschema:"oneof_required=jsonschema"`
+	// Assert is a generator which checks each document of its input against CEL rules and returns the input unaltered.
+	Assert *AssertGenerator `yaml:"assert,omitempty" json:"assert,omitempty" jsonschema:"oneof_required=assert"`
+	// Starlark is a generator which executes a Starlark script and returns the documents it emits.
+	Starlark *StarlarkGenerator `yaml:"starlark,omitempty" json:"starlark,omitempty" jsonschema:"oneof_required=starlark"`
+	// Jsonnet is a generator which evaluates a Jsonnet program and returns the result.
+	Jsonnet *JsonnetGenerator `yaml:"jsonnet,omitempty" json:"jsonnet,omitempty" jsonschema:"oneof_required=jsonnet"`
+	// CUE is a generator which evaluates CUE files, unified with values from the pipeline, and exports the result.
+	CUE *CUEGenerator `yaml:"cue,omitempty" json:"cue,omitempty" jsonschema:"oneof_required=cue"`
+	// TOML is a generator which returns it's input as TOML.
+	TOML *FormatGenerator `yaml:"toml,omitempty" json:"toml,omitempty" jsonschema:"oneof_required=toml"`
+	// HCL is a generator which returns it's input as HCL (version 1).
+	HCL *FormatGenerator `yaml:"hcl,omitempty" json:"hcl,omitempty" jsonschema:"oneof_required=hcl"`
+	// INI is a generator which returns it's input as an INI file. Nested objects become sections.
+	INI *FormatGenerator `yaml:"ini,omitempty" json:"ini,omitempty" jsonschema:"oneof_required=ini"`
+	// Dotenv is a generator which returns it's input as a .env file.
+	Dotenv *FormatGenerator `yaml:"dotenv,omitempty" json:"dotenv,omitempty" jsonschema:"oneof_required=dotenv"`
+	// Properties is a generator which returns it's input as a Java properties file. Keys of nested objects are joined with dots.
+	Properties *FormatGenerator `yaml:"properties,omitempty" json:"properties,omitempty" jsonschema:"oneof_required=properties"`
+	// XML is a generator which returns it's input as XML.
+	XML *FormatGenerator `yaml:"xml,omitempty" json:"xml,omitempty" jsonschema:"oneof_required=xml"`
+	// CSV is a generator which returns it's input as CSV, with each document as a row.
+	CSV *FormatGenerator `yaml:"csv,omitempty" json:"csv,omitempty" jsonschema:"oneof_required=csv"`
+	// Encode is a generator which returns it's input encoded in the specified format.
+	Encode *EncodeGenerator `yaml:"encode,omitempty" json:"encode,omitempty" jsonschema:"oneof_required=encode"`
+	// HTTP is a generator which returns the body of an HTTP request.
+	HTTP *HTTPGenerator `yaml:"http,omitempty" json:"http,omitempty" jsonschema:"oneof_required=http"`
 }
 
 // FileGenerator reads files at the specified path and returns their output.
 type FileGenerator struct {
 	// Path is the path relative to this pipeline file to read.
-	Path string `yaml:"path" json:"path"`
+	Path string `yaml:"path" json:"path" interpolate:"true"`
 }
 
 // ExecGenerator execs the command specified and returns the stdout of the program.
@@ -73,7 +116,7 @@ type HelmGenerator struct {
 	// APIVersions are Kubernetes api versions used for Capabilities.APIVersions.
 	APIVersions []StringOrValue `yaml:"apiVersions,omitempty" json:"apiVersions,omitempty"`
 	// Values are the Helm values used as configuration for the Helm chart.
-	Values []StringOrValue `yaml:"values,omitempty" json:"values,omitempty"`
+	Values []StringOrValue `yaml:"values,omitempty" json:"values,omitempty" interpolate:"false"`
 }
 
 // KustomizeGenerator runs 'kustomize build' to render a Kustomization and returns the output.
@@ -89,23 +132,53 @@ type KustomizeGenerator struct {
 // MergeGenerator takes multiple inputs containing object-like data and deeply merges them together and returns the merged output.
 type MergeGenerator struct {
 	// Inputs are the inputs to merge. Inputs specified later in the list take precedence, overwriting values in earlier inputs.
-	Input []MapOrValue `yaml:"input" json:"input"`
+	Input []MapOrValue `yaml:"input,omitempty" json:"input,omitempty" jsonschema:"oneof_required=input"`
+	// Streams are document streams to merge. Documents are grouped by their
+	// Kubernetes identity (apiVersion, kind, namespace and name), and documents
+	// with the same identity are merged in input order, returning a
+	// de-duplicated stream. Documents without an identity are returned
+	// unaltered.
+	Streams []Value `yaml:"streams,omitempty" json:"streams,omitempty" jsonschema:"oneof_required=streams"`
+	// OnConflict configures how conflicting values are handled when merging
+	// streams. A conflict occurs when documents with the same identity set a
+	// field to different values. Valid options are ignore, warn or error.
+	OnConflict StringOrValue `yaml:"onConflict,omitempty" json:"onConflict,omitempty"`
+	// Preserve returns YAML which retains the comments, key order and anchors
+	// of the first input, only changing the values modified by later inputs.
+	// The first input must be YAML, such as a file. Not supported with streams.
+	Preserve BoolOrValue `yaml:"preserve,omitempty" json:"preserve,omitempty"`
 }
 
 // GoTemplateGenerator renders Go 'text/template' templates and returns the output.
 type GoTemplateGenerator struct {
 	// Template is the template to render.
-	Template StringOrValue `yaml:"template" json:"template"`
+	Template StringOrValue `yaml:"template" json:"template" interpolate:"false"`
 	// Vars are input variables to the template.
 	Vars map[string]AnyOrValue `yaml:"vars,omitempty" json:"vars,omitempty"`
+	// Partials are glob patterns of files relative to this pipeline file containing templates, such as 'define' blocks, which are loaded alongside the template.
+	Partials []StringOrValue `yaml:"partials,omitempty" json:"partials,omitempty"`
+	// Delims are the delimiters of actions in the template, which default to {{ and }}. Useful when the output itself contains {{ }}.
+	Delims *GoTemplateDelims `yaml:"delims,omitempty" json:"delims,omitempty" interpolate:"false"`
+	// MissingKey controls the behavior when a map is indexed with a key which doesn't exist. 'error' (the default) stops execution with an error, 'zero' prints missing values as empty strings and 'default' prints "<no value>".
+	MissingKey StringOrValue `yaml:"missingKey,omitempty" json:"missingKey,omitempty"`
+	// Input is a stream of documents. When set, the template is rendered once for each document with the document as '.', and the results are returned as a YAML stream. Cannot be combined with vars.
+	Input *Value `yaml:"input,omitempty" json:"input,omitempty" interpolate:"false"`
+}
+
+// GoTemplateDelims are the delimiters of template actions.
+type GoTemplateDelims struct {
+	// Left is the left delimiter.
+	Left StringOrValue `yaml:"left" json:"left"`
+	// Right is the right delimiter.
+	Right StringOrValue `yaml:"right" json:"right"`
 }
 
 // JQGenerator executes 'jq' and returns the output.
 type JQGenerator struct {
 	// Expr is the jq expression to evaluate.
-	Expr StringOrValue `yaml:"expr,omitempty" json:"expr,omitempty"`
+	Expr StringOrValue `yaml:"expr,omitempty" json:"expr,omitempty" interpolate:"false"`
 	// Input is the JSON input for jq to execute the expression over.
-	Input StringOrValue `yaml:"input" json:"input"`
+	Input StringOrValue `yaml:"input" json:"input" interpolate:"false"`
 	// Slurp configures jq to read all inputs into an array and use it as a single input value.
 	Slurp BoolOrValue `yaml:"slurp,omitempty" json:"slurp,omitempty"`
 }
@@ -114,40 +187,283 @@ type JQGenerator struct {
 type CELGenerator struct {
 	// Input values then evaluated against the configure CEL expression.
 	Input *Value `yaml:"input" json:"input"`
-	// Expr is a CEL expression evaluated with the input set to the variable 'val'.
-	Expr StringOrValue `yaml:"expr" json:"expr"`
+	// Expr is a CEL expression evaluated with the input set to the variable 'val', the pipeline variables set to 'vars' and the output of previous stages set to 'refs'.
+	Expr StringOrValue `yaml:"expr" json:"expr" interpolate:"false"`
 	// When filter is true, the CEL expression becomes a filter returning a boolean indicating if the input should be kept.
 	Filter BoolOrValue `yaml:"filter,omitempty" json:"filter,omitempty"`
 	// If Filter and InvertFilter is true, instead of keeping the result, it will be discarded.
 	InvertFilter BoolOrValue `yaml:"invertFilter,omitempty" json:"invertFilter,omitempty"`
 	// Collect configures the generator to read all inputs into an array and use it as a single input value.
 	Collect BoolOrValue `yaml:"collect,omitempty" json:"collect,omitempty"`
+	// When flatMap is true, the CEL expression must return a list, and each item of the list is returned as a separate document.
+	FlatMap BoolOrValue `yaml:"flatMap,omitempty" json:"flatMap,omitempty"`
+	// When groupBy is true, the CEL expression returns the key of each input, and the result is a map from each key to the list of inputs with that key.
+	GroupBy BoolOrValue `yaml:"groupBy,omitempty" json:"groupBy,omitempty"`
+	// When reduce is true, the CEL expression is evaluated for each input with the result of the previous evaluation set to the variable 'acc', and the final result is returned.
+	Reduce BoolOrValue `yaml:"reduce,omitempty" json:"reduce,omitempty"`
+	// Initial is the value of 'acc' when reduce evaluates the first input. Defaults to null.
+	Initial *AnyOrValue `yaml:"initial,omitempty" json:"initial,omitempty"`
 }
 
 // JSONPatchGenerator evaluates a JSONPatch against the input.
 type JSONPatchGenerator struct {
 	// Input is the value to apply the patch to. It must be JSON.
-	Input StringOrValue `yaml:"input" json:"input"`
+	Input StringOrValue `yaml:"input" json:"input" interpolate:"false"`
 	// Patch is the JSON patch. If it is YAML, it will be automatically converted to JSON.
-	Patch StringOrValue `yaml:"patch" json:"patch"`
+	Patch StringOrValue `yaml:"patch" json:"patch" interpolate:"false"`
 	// If merge is true, then patch is interpreted as a JSON merge patch.
 	Merge BoolOrValue `yaml:"merge,omitempty" json:"merge,omitempty"`
+	// Preserve allows the input to be YAML, and returns YAML which retains the
+	// comments, key order and anchors of the input, only changing the values
+	// modified by the patch.
+	Preserve BoolOrValue `yaml:"preserve,omitempty" json:"preserve,omitempty"`
 }
 
 // YAMLGenerator returns it's inputs as YAML.
 type YAMLGenerator struct {
 	// Inputs are the inputs to convert to YAML. If a single input produces multiple objects or multiple inputs are provided, a stream of YAML documents is returned.
 	Input []Value `yaml:"input" json:"input"`
+	// Preserve returns inputs which are already YAML, such as files, as they
+	// are, retaining their comments, key order and anchors.
+	Preserve   BoolOrValue `yaml:"preserve,omitempty" json:"preserve,omitempty"`
+	YAMLFormat `yaml:",inline" json:",inline"`
+}
+
+// YAMLFormat configures how YAML is written.
+type YAMLFormat struct {
 	// Indent defines the indent level to use for the output.
 	Indent int `yaml:"indent,omitempty" json:"indent,omitempty"`
+	// QuoteType is the type of quotes used for quoted strings. Valid options are double or single. Defaults to double.
+	QuoteType string `yaml:"quoteType,omitempty" json:"quoteType,omitempty" jsonschema:"enum=double,enum=single"`
+	// QuoteAll quotes every string value, instead of only strings which would otherwise be read as another type.
+	QuoteAll bool `yaml:"quoteAll,omitempty" json:"quoteAll,omitempty"`
+	// MultilineStyle is the style of strings containing line breaks. Valid options are literal, for literal block scalars, or quoted. Defaults to literal.
+	MultilineStyle string `yaml:"multilineStyle,omitempty" json:"multilineStyle,omitempty" jsonschema:"enum=literal,enum=quoted"`
+	// FlowSequences writes sequences of at most this many scalars in flow style, such as [a, b].
+	FlowSequences int `yaml:"flowSequences,omitempty" json:"flowSequences,omitempty"`
+	// SortKeys sorts the keys of mappings. The keys of objects are always sorted when encoded, so this only affects YAML which is returned as it is, such as when using preserve. The keys of a mapping are left unsorted if sorting them would move an alias before its anchor.
+	SortKeys bool `yaml:"sortKeys,omitempty" json:"sortKeys,omitempty"`
+	// DocumentStart writes a document start marker (---) before the first document, in addition to between documents.
+	DocumentStart bool `yaml:"documentStart,omitempty" json:"documentStart,omitempty"`
+	// DocumentEnd writes a document end marker (...) after every document.
+	DocumentEnd bool `yaml:"documentEnd,omitempty" json:"documentEnd,omitempty"`
+	// NullValue is how null values are written. Valid options are null, ~, or empty, which leaves the values of keys empty.
+	NullValue string `yaml:"nullValue,omitempty" json:"nullValue,omitempty" jsonschema:"enum=null,enum=~,enum=empty"`
+	// LineWidth is the maximum length of lines. Longer strings containing spaces are written as folded block scalars. Lines are not limited by default.
+	LineWidth int `yaml:"lineWidth,omitempty" json:"lineWidth,omitempty"`
 }
 
 // JSONGenerator returns it's inputs as JSON.
 type JSONGenerator struct {
 	// Inputs are the inputs to convert to JSON. If a single input produces multiple objects or multiple inputs are provided, a stream of YAML objects is returned.
 	Input []Value `yaml:"input" json:"input"`
-	// Indent defines the indent level to use for the output.
+	// Indent defines the indent level to use for the output. It cannot be used with the lines mode or canonical.
 	Indent int `yaml:"indent,omitempty" json:"indent,omitempty"`
+	// Mode defines how multiple documents are written. Valid options are
+	// stream, which writes each document after the previous, array, which
+	// writes a single array containing the documents, or lines, which writes
+	// each document on a single line (JSON Lines). Defaults to stream.
+	Mode StringOrValue `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// Canonical writes documents as canonical JSON (RFC 8785), with sorted
+	// keys, normalized numbers and no whitespace, so that equal documents are
+	// always written identically.
+	Canonical BoolOrValue `yaml:"canonical,omitempty" json:"canonical,omitempty"`
+	// DisableHTMLEscape disables escaping the characters <, > and & in strings.
+	DisableHTMLEscape BoolOrValue `yaml:"disableHTMLEscape,omitempty" json:"disableHTMLEscape,omitempty"`
+}
+
+// KubernetesGenerator applies common transformations to a stream of Kubernetes resources.
+type KubernetesGenerator struct {
+	// Input are the Kubernetes resources to transform.
+	Input []Value `yaml:"input" json:"input"`
+	// Namespace sets the namespace of all namespaced resources. Cluster-scoped resources are left unaltered.
+	Namespace StringOrValue `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	// Labels are labels to add to all resources and pod templates.
+	Labels MapOrValue `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// IncludeSelectors configures labels to also be added to the selectors of workloads and services.
+	IncludeSelectors BoolOrValue `yaml:"includeSelectors,omitempty" json:"includeSelectors,omitempty"`
+	// Annotations are annotations to add to all resources and pod templates.
+	Annotations MapOrValue `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	// NamePrefix is prepended to the name of all resources. References to renamed resources, such as a ConfigMap mounted by a Deployment, are updated.
+	NamePrefix StringOrValue `yaml:"namePrefix,omitempty" json:"namePrefix,omitempty"`
+	// NameSuffix is appended to the name of all resources. References to renamed resources, such as a ConfigMap mounted by a Deployment, are updated.
+	NameSuffix StringOrValue `yaml:"nameSuffix,omitempty" json:"nameSuffix,omitempty"`
+	// Images overrides the name, tag or digest of container images.
+	Images []ImageOverride `yaml:"images,omitempty" json:"images,omitempty"`
+}
+
+// ImageOverride overrides the name, tag or digest of matching container images.
+type ImageOverride struct {
+	// Name is the name of the image to override, without a tag or digest.
+	Name string `yaml:"name" json:"name"`
+	// NewName replaces the name of the image.
+	NewName StringOrValue `yaml:"newName,omitempty" json:"newName,omitempty"`
+	// NewTag replaces the tag of the image, removing any digest.
+	NewTag StringOrValue `yaml:"newTag,omitempty" json:"newTag,omitempty"`
+	// Digest replaces the digest of the image, removing any tag.
+	Digest StringOrValue `yaml:"digest,omitempty" json:"digest,omitempty"`
+}
+
+// ConfigMapGenerator builds a Kubernetes ConfigMap from its inputs.
+type ConfigMapGenerator struct {
+	// Name is the name of the generated object. Unless disableNameSuffixHash is true, a hash of the content is appended to the name.
+	Name StringOrValue `yaml:"name" json:"name"`
+	// Namespace is the namespace of the generated object.
+	Namespace StringOrValue `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	// Data are the entries of the generated object, keyed by the name of each value. If a file value has no name, the base name of the file is used. A glob or dir value with asMap set and no name adds an entry for each file, keyed by its relative path. Keys may only contain alphanumeric characters, '-', '_' and '.', so files in subdirectories cannot be added this way.
+	Data []NamedValue `yaml:"data,omitempty" json:"data,omitempty"`
+	// Files are glob patterns relative to this pipeline file. Each matching file is added to the generated object using its base name as the key.
+	Files []StringOrValue `yaml:"files,omitempty" json:"files,omitempty"`
+	// Labels are labels to add to the generated object.
+	Labels MapOrValue `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// Annotations are annotations to add to the generated object.
+	Annotations MapOrValue `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	// DisableNameSuffixHash disables appending a hash of the content to the name.
+	DisableNameSuffixHash BoolOrValue `yaml:"disableNameSuffixHash,omitempty" json:"disableNameSuffixHash,omitempty"`
+	// Resources are Kubernetes resources to update references to the generated object in, such as a Deployment mounting the object as a volume. The generated object is returned followed by the updated resources.
+	Resources []Value `yaml:"resources,omitempty" json:"resources,omitempty"`
+}
+
+// SecretGenerator builds a Kubernetes Secret from its inputs.
+type SecretGenerator struct {
+	ConfigMapGenerator `yaml:",inline" json:",inline"`
+	// Type is the type of the Secret. Defaults to Opaque.
+	Type StringOrValue `yaml:"type,omitempty" json:"type,omitempty"`
+}
+
+// SortGenerator sorts a stream of Kubernetes resources into the order they should be applied in.
+type SortGenerator struct {
+	// Input are the Kubernetes resources to sort.
+	Input []Value `yaml:"input" json:"input"`
+	// Order is the order of kinds to sort resources by. Resources of the same kind are sorted by name, and kinds not in the list are sorted after known kinds alphabetically. Defaults to the order Helm installs resources in.
+	Order []StringOrValue `yaml:"order,omitempty" json:"order,omitempty"`
+}
+
+// ValidateGenerator validates Kubernetes resources against their OpenAPI schemas and returns them unaltered.
+type ValidateGenerator struct {
+	// Input are the Kubernetes resources to validate.
+	Input []Value `yaml:"input" json:"input"`
+	// KubernetesVersion is the version of Kubernetes to validate resources against, for example 1.31.0. Defaults to master.
+	KubernetesVersion StringOrValue `yaml:"kubernetesVersion,omitempty" json:"kubernetesVersion,omitempty"`
+	// SchemaDirs are directories relative to this pipeline file containing Kubernetes JSON schemas, using the layout of github.com/yannh/kubernetes-json-schema. For example, the schema of a apps/v1 Deployment for Kubernetes 1.31.0 is read from <dir>/v1.31.0-standalone/deployment-apps-v1.json.
+	SchemaDirs []StringOrValue `yaml:"schemaDirs,omitempty" json:"schemaDirs,omitempty"`
+	// Strict configures validation to use the strict schemas, which reject unknown fields. The schemas are read from the -standalone-strict directories instead.
+	Strict BoolOrValue `yaml:"strict,omitempty" json:"strict,omitempty"`
+	// CRDs are CustomResourceDefinitions used to validate custom resources. CustomResourceDefinitions in the input are also used.
+	CRDs []Value `yaml:"crds,omitempty" json:"crds,omitempty"`
+	// IgnoreMissingSchemas skips validating resources without a schema instead of returning an error.
+	IgnoreMissingSchemas BoolOrValue `yaml:"ignoreMissingSchemas,omitempty" json:"ignoreMissingSchemas,omitempty"`
+}
+
+// JSONSchemaGenerator validates its input against a JSON schema and returns the input unaltered.
+type JSONSchemaGenerator struct {
+	// Input is the value to validate. If the input contains multiple documents, each document is validated.
+	Input Value `yaml:"input" json:"input"`
+	// Schema is the JSON schema to validate the input against, such as the values.schema.json of a Helm chart. References to other schemas are resolved relative to the schema file when the schema is read from a file.
+	Schema AnyOrValue `yaml:"schema" json:"schema"`
+}
+
+// AssertGenerator checks each document of its input against CEL rules and returns the input unaltered.
+type AssertGenerator struct {
+	// Input are the documents to check.
+	Input []Value `yaml:"input" json:"input"`
+	// Rules are the rules each document is checked against.
+	Rules []AssertRule `yaml:"rules" json:"rules"`
+}
+
+// AssertRule is a rule documents are checked against.
+type AssertRule struct {
+	// Expr is a CEL expression evaluated with the document set to the variable 'val'. It must return true for the document to pass the rule.
+	Expr StringOrValue `yaml:"expr" json:"expr" interpolate:"false"`
+	// Message is reported for each document which fails the rule.
+	Message StringOrValue `yaml:"message" json:"message"`
+	// Severity is the severity of the rule. Valid options are error or warning. Documents failing rules with a severity of error fail the pipeline, while warnings are printed. Defaults to error.
+	Severity StringOrValue `yaml:"severity,omitempty" json:"severity,omitempty"`
+}
+
+// StarlarkGenerator executes a Starlark script and returns the documents it emits.
+type StarlarkGenerator struct {
+	// Script is the Starlark script to execute.
+	// The pipeline variables and the output of previous stages are available as the dicts 'vars' and 'refs'.
+	// Documents are returned by calling 'emit(doc, ...)', or if nothing is emitted, by setting the global 'output'.
+	// The modules 'yaml' and 'json' provide encode and decode functions.
+	Script StringOrValue `yaml:"script" json:"script" interpolate:"false"`
+	// MaxSteps is the maximum number of computation steps the script can execute before it's cancelled, which stops scripts which never finish. Defaults to 100000000.
+	MaxSteps int `yaml:"maxSteps,omitempty" json:"maxSteps,omitempty"`
+}
+
+// JsonnetGenerator evaluates a Jsonnet program and returns the result.
+type JsonnetGenerator struct {
+	// Snippet is the Jsonnet program to evaluate. Imports are resolved relative to the file it's read from, or this pipeline file.
+	Snippet StringOrValue `yaml:"snippet" json:"snippet" interpolate:"false"`
+	// ExtVars are external variables, accessed with std.extVar. Strings are passed as is, and other values are passed as Jsonnet values.
+	ExtVars []NamedValue `yaml:"extVars,omitempty" json:"extVars,omitempty"`
+	// TLAs are the top-level arguments passed when the program evaluates to a function. Strings are passed as is, and other values are passed as Jsonnet values.
+	TLAs []NamedValue `yaml:"tlas,omitempty" json:"tlas,omitempty"`
+	// JPath is a list of library search paths relative to this pipeline file.
+	JPath []StringOrValue `yaml:"jpath,omitempty" json:"jpath,omitempty"`
+	// When stream is true, the program must evaluate to an array, and each item is returned as a separate document.
+	// Programs evaluating to a string, such as the output of std.manifestYamlStream, are parsed as a YAML stream instead.
+	Stream BoolOrValue `yaml:"stream,omitempty" json:"stream,omitempty"`
+	// Format is the format of the result when the program evaluates to a string, such as yaml for the output of std.manifestYamlDoc.
+	// Strings are returned as text by default, or as YAML if stream is true.
+	Format StringOrValue `yaml:"format,omitempty" json:"format,omitempty"`
+}
+
+// CUEGenerator evaluates CUE files or packages, unified with values from the pipeline, and exports the result.
+type CUEGenerator struct {
+	// Paths are the CUE files or package directories to evaluate, relative to this pipeline file.
+	Paths []StringOrValue `yaml:"paths" json:"paths"`
+	// Input values are unified with the CUE values, failing if they violate any constraints.
+	Input []CUEInput `yaml:"input,omitempty" json:"input,omitempty"`
+	// Expression is the CUE path of the value to export, for example 'objects'. Defaults to the entire value.
+	Expression StringOrValue `yaml:"expression,omitempty" json:"expression,omitempty" interpolate:"false"`
+	// When stream is true, the exported value must be a list, and each item is returned as a separate document.
+	Stream BoolOrValue `yaml:"stream,omitempty" json:"stream,omitempty"`
+}
+
+// CUEInput is a value unified with the CUE values at a path.
+type CUEInput struct {
+	// Path is the CUE path to unify the value with, for example 'values'. Defaults to the root.
+	Path  StringOrValue `yaml:"path,omitempty" json:"path,omitempty"`
+	Value `yaml:",inline" json:",inline"`
+}
+
+// FormatGenerator returns it's inputs encoded in a data format.
+type FormatGenerator struct {
+	// Inputs are the inputs to encode. Formats other than CSV only support a single document.
+	Input []Value `yaml:"input" json:"input"`
+}
+
+// EncodeGenerator returns it's inputs encoded in the specified format.
+type EncodeGenerator struct {
+	// Format is the name of the format to encode the inputs in, such as yaml, json or toml.
+	Format StringOrValue `yaml:"format" json:"format"`
+	// Inputs are the inputs to encode. Formats which only contain a single document require a single input document.
+	Input []Value `yaml:"input" json:"input"`
+}
+
+// HTTPGenerator makes an HTTP request and returns the response body.
+// Responses with a checksum, or with cache enabled, are cached, and are read
+// from the cache when running offline, or when the cached response matches the
+// checksum.
+type HTTPGenerator struct {
+	// URL is the URL to request.
+	URL StringOrValue `yaml:"url" json:"url"`
+	// Method is the HTTP method to use. Defaults to GET.
+	Method StringOrValue `yaml:"method,omitempty" json:"method,omitempty"`
+	// Headers are the request headers, keyed by the name of each value.
+	Headers []NamedValue `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Body is the request body.
+	Body StringOrValue `yaml:"body,omitempty" json:"body,omitempty" interpolate:"false"`
+	// Checksum is the expected checksum of the response body, in the form sha256:<hex> or sha512:<hex>. The request fails if the checksum doesn't match.
+	Checksum StringOrValue `yaml:"checksum,omitempty" json:"checksum,omitempty"`
+	// Format is the format of the response body. Defaults to the format of the Content-Type header, or the extension of the URL path.
+	Format StringOrValue `yaml:"format,omitempty" json:"format,omitempty"`
+	// Cache caches the response even if no checksum is set, so the pipeline can be re-run with --offline.
+	// Responses are stored unencrypted in the http directory of the cache directory, which defaults to yamlforge/http in the user cache directory, such as ~/.cache/yamlforge/http on Linux.
+	// Avoid caching responses containing secrets.
+	Cache BoolOrValue `yaml:"cache,omitempty" json:"cache,omitempty"`
 }
 
 // PipelineGenerator executes other generators in a pipeline or singular context.
@@ -4,6 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"strings"
+
+	"github.com/chancez/yamlforge/pkg/cellib"
 )
 
 func ParseFile(forgeFile string) (Config, error) {
@@ -39,6 +43,10 @@ func ValidatePipelineGenerators(cfg PipelineGenerator) error {
 		if err != nil {
 			return fmt.Errorf("validation error: %w", err)
 		}
+		err = validateTemplates(*cfg.Generator)
+		if err != nil {
+			return fmt.Errorf("validation error: %w", err)
+		}
 	}
 
 	generatorPositions := make(map[string]int)
@@ -53,6 +61,10 @@ func ValidatePipelineGenerators(cfg PipelineGenerator) error {
 		if err != nil {
 			return fmt.Errorf("validation error: pipeline[%d] %s: %w", pos, gen.Name, err)
 		}
+		err = validateTemplates(gen)
+		if err != nil {
+			return fmt.Errorf("validation error: pipeline[%d] %s: %w", pos, gen.Name, err)
+		}
 		generatorPositions[gen.Name] = pos
 	}
 
@@ -100,6 +112,63 @@ func validateGenerators(generatorCfg Generator) error {
 	if generatorCfg.JSON != nil {
 		count++
 	}
+	if generatorCfg.Kubernetes != nil {
+		count++
+	}
+	if generatorCfg.ConfigMap != nil {
+		count++
+	}
+	if generatorCfg.Secret != nil {
+		count++
+	}
+	if generatorCfg.Sort != nil {
+		count++
+	}
+	if generatorCfg.Validate != nil {
+		count++
+	}
+	if generatorCfg.JSONSchema != nil {
+		count++
+	}
+	if generatorCfg.Assert != nil {
+		count++
+	}
+	if generatorCfg.Starlark != nil {
+		count++
+	}
+	if generatorCfg.Jsonnet != nil {
+		count++
+	}
+	if generatorCfg.CUE != nil {
+		count++
+	}
+	if generatorCfg.TOML != nil {
+		count++
+	}
+	if generatorCfg.HCL != nil {
+		count++
+	}
+	if generatorCfg.INI != nil {
+		count++
+	}
+	if generatorCfg.Dotenv != nil {
+		count++
+	}
+	if generatorCfg.Properties != nil {
+		count++
+	}
+	if generatorCfg.XML != nil {
+		count++
+	}
+	if generatorCfg.CSV != nil {
+		count++
+	}
+	if generatorCfg.Encode != nil {
+		count++
+	}
+	if generatorCfg.HTTP != nil {
+		count++
+	}
 	if count == 0 {
 		return fmt.Errorf("generator not configured")
 	}
@@ -108,3 +177,111 @@ func validateGenerators(generatorCfg Generator) error {
 	}
 	return nil
 }
+
+// validateTemplates checks that the ${{ }} expressions in the string fields of
+// the generator compile if it enables interpolation, and of any nested
+// generators which do.
+func validateTemplates(generatorCfg Generator) error {
+	check := func(path, s string) error {
+		return nil
+	}
+	if generatorCfg.Interpolate {
+		env, err := cellib.NewEnv()
+		if err != nil {
+			return fmt.Errorf("error creating CEL environment: %w", err)
+		}
+		check = func(path, s string) error {
+			if !strings.Contains(s, "${{") {
+				return nil
+			}
+			tmpl, err := cellib.ParseTemplate(s)
+			if err == nil {
+				err = tmpl.Check(env)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			return nil
+		}
+	}
+	nested := func(path string, gen Generator) error {
+		if err := validateTemplates(gen); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	}
+	return walkInterpolatedStrings(reflect.ValueOf(generatorCfg), "", false, check, nested)
+}
+
+var (
+	stringOrValueType = reflect.TypeOf(StringOrValue{})
+	generatorType     = reflect.TypeOf(Generator{})
+)
+
+// walkInterpolatedStrings calls fn with the path and string of each string
+// within v which is interpolated. These are the strings of StringOrValue
+// fields, except those tagged with interpolate:"false" such as templates,
+// expressions and data, and string fields tagged with interpolate:"true" such
+// as file paths. If raw is true, the strings of StringOrValues within v are
+// skipped. Values within interfaces, such as the data of a value, are skipped,
+// and nested is called with each nested generator instead of walking it.
+func walkInterpolatedStrings(v reflect.Value, path string, raw bool, fn func(path, s string) error, nested func(path string, gen Generator) error) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return walkInterpolatedStrings(v.Elem(), path, raw, fn, nested)
+	case reflect.Struct:
+		if v.Type() == generatorType && path != "" {
+			return nested(path, v.Interface().(Generator))
+		}
+		if v.Type() == stringOrValueType {
+			sv := v.Interface().(StringOrValue)
+			if sv.String != nil && !raw {
+				return fn(path, *sv.String)
+			}
+			if sv.Value != nil {
+				return walkInterpolatedStrings(reflect.ValueOf(sv.Value), path, false, fn, nested)
+			}
+			return nil
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			fieldPath := path
+			if name != "" && name != "-" {
+				fieldPath = strings.TrimPrefix(path+"."+name, ".")
+			}
+			tag := field.Tag.Get("interpolate")
+			if field.Type.Kind() == reflect.String {
+				if s := v.Field(i).String(); tag == "true" && s != "" {
+					if err := fn(fieldPath, s); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := walkInterpolatedStrings(v.Field(i), fieldPath, tag == "false", fn, nested); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkInterpolatedStrings(v.Index(i), fmt.Sprintf("%s[%d]", path, i), raw, fn, nested); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := walkInterpolatedStrings(iter.Value(), strings.TrimPrefix(fmt.Sprintf("%s.%v", path, iter.Key()), "."), raw, fn, nested); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValidatesTemplates(t *testing.T) {
+	_, err := Parse([]byte(`
+pipeline:
+- name: chart
+  interpolate: true
+  helm:
+    releaseName: ${{ vars.env }}-app
+    chart: ./chart
+    version: $${{ escaped }}
+`))
+	require.NoError(t, err)
+
+	_, err = Parse([]byte(`
+pipeline:
+- name: chart
+  interpolate: true
+  exec:
+    command: echo
+    args:
+      - ok
+      - ${{ vars.env + }}
+`))
+	assert.ErrorContains(t, err, "validation error: pipeline[0] chart: exec.args[1]: error compiling expression")
+
+	_, err = Parse([]byte(`
+pipeline:
+- name: chart
+  interpolate: true
+  exec:
+    command: ${{ vars.env
+`))
+	assert.ErrorContains(t, err, "exec.command: unterminated expression")
+
+	_, err = Parse([]byte(`
+pipeline:
+- name: values
+  interpolate: true
+  file:
+    path: ${{ vars.env + }}.yaml
+`))
+	assert.ErrorContains(t, err, "file.path: error compiling expression")
+
+	// The file paths of the values of templates are checked.
+	_, err = Parse([]byte(`
+pipeline:
+- name: template
+  interpolate: true
+  gotemplate:
+    template:
+      file: ${{ vars.env + }}.tmpl
+`))
+	assert.ErrorContains(t, err, "gotemplate.template.file: error compiling expression")
+
+	// Stages which don't enable interpolation, and templates, expressions and
+	// data, aren't checked.
+	_, err = Parse([]byte(`
+pipeline:
+- name: chart
+  exec:
+    command: ${{ vars.env
+- name: template
+  interpolate: true
+  gotemplate:
+    template: ${{ github.sha }}
+- name: cel
+  interpolate: true
+  cel:
+    expr: '"${{ github.sha }}"'
+- name: jq
+  interpolate: true
+  jq:
+    expr: .
+    input: '{"sha": "${{ github.sha }}"}'
+- name: value
+  interpolate: true
+  value:
+    value: ${{ github.sha }}
+`))
+	require.NoError(t, err)
+
+	// Nested stages are checked if they enable interpolation.
+	_, err = Parse([]byte(`
+pipeline:
+- name: nested
+  pipeline:
+    pipeline:
+    - name: chart
+      interpolate: true
+      exec:
+        command: ${{ vars.env
+`))
+	assert.ErrorContains(t, err, "validation error: pipeline[0] nested: pipeline.pipeline[0]: exec.command: unterminated expression")
+}
@@ -93,7 +93,7 @@ func maybeUnmarshalValue(data []byte, val **Value) (bool, error) {
 			return false, err
 		}
 		valueKeys := []string{
-			"var", "ref", "file", "env", "value", "values",
+			"var", "ref", "file", "glob", "dir", "http", "git", "sops", "age", "env", "value", "values",
 			"pipeline", "generator", "import", "include",
 		}
 		for _, key := range valueKeys {
@@ -191,7 +191,26 @@ type Value struct {
 	// Ref takes the name of a previous stage in the pipeline and returns the output of that stage.
 	Ref string `yaml:"ref,omitempty" json:"ref,omitempty" jsonschema:"oneof_required=ref"`
 	// File takes a path relative to this pipeline file to read and returns the content of the file specified.
-	File string `yaml:"file,omitempty" json:"file,omitempty" jsonschema:"oneof_required=file"`
+	File string `yaml:"file,omitempty" json:"file,omitempty" jsonschema:"oneof_required=file" interpolate:"true"`
+	// Glob takes a glob pattern relative to this pipeline file and returns the
+	// documents of each matching file in lexical order, parsed using the
+	// extension of each file, or the format option if it's unknown.
+	Glob string `yaml:"glob,omitempty" json:"glob,omitempty" jsonschema:"oneof_required=glob" interpolate:"true"`
+	// Dir takes a directory relative to this pipeline file and returns the
+	// documents of every file within it, including sub-directories, like glob.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty" jsonschema:"oneof_required=dir" interpolate:"true"`
+	// AsMap returns the files read by glob or dir as a map of the path of each
+	// file, relative to dir or the directory of the pattern, to its content
+	// instead of parsing them.
+	AsMap bool `yaml:"asMap,omitempty" json:"asMap,omitempty"`
+	// HTTP makes an HTTP request and returns the response body.
+	HTTP *HTTPGenerator `yaml:"http,omitempty" json:"http,omitempty" jsonschema:"oneof_required=http"`
+	// Git reads files from a git repository at a specific revision.
+	Git *GitSource `yaml:"git,omitempty" json:"git,omitempty" jsonschema:"oneof_required=git"`
+	// SOPS decrypts a file encrypted with SOPS.
+	SOPS *SOPSSource `yaml:"sops,omitempty" json:"sops,omitempty" jsonschema:"oneof_required=sops"`
+	// Age decrypts a file encrypted with age.
+	Age *AgeSource `yaml:"age,omitempty" json:"age,omitempty" jsonschema:"oneof_required=age"`
 	// Env takes the name of an environment variable and returns its value.
 	Env string `yaml:"env,omitempty" json:"env,omitempty" jsonschema:"oneof_required=env"`
 	// Value simply returns the value specified. It can be any valid YAML/JSON type (string, boolean, number, array, object), or another Value
@@ -202,13 +221,66 @@ type Value struct {
 	// Value simply returns the value specified. It can be any valid YAML/JSON type ( string, boolean, number, array, object), or another Value.
 	// IgnoreMissing specifies if the generator should ignore missing references or files. If set to true, the generator will return an empty string instead of an error.
 	IgnoreMissing bool `yaml:"ignoreMissing,omitempty" json:"ignoreMissing,omitempty"`
-	// Default specifies the default value to use if a ref, variable, or file is
-	// missing. Has no effect unless ignoreMissing is true.
+	// Default specifies the default value to use if a ref, variable, file or
+	// directory is missing, if a glob matches no files, if an http request
+	// returns 404 Not Found, or if a file doesn't exist in a git repository.
+	// Has no effect unless ignoreMissing is true.
 	// It can be any valid YAML/JSON type ( string, boolean, number, array, object).
 	Default any `yaml:"default,omitempty" json:"default,omitempty" jsonschema:"oneof_type=string;boolean;number;array;object"`
+	// Path selects a sub-value using a JSON Pointer such as /spec/replicas, or a
+	// dotted path such as spec.replicas. Outputs with multiple documents are
+	// treated as a list of documents.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Select selects sub-values using a JSONPath expression such as
+	// $.items[*].name. Expressions which can only match a single value, such as
+	// $.spec.replicas, return that value, otherwise a list of the matches is
+	// returned. If set with path, it's applied to the result of path.
+	// IgnoreMissing and Default also apply when path or select don't match.
+	Select string `yaml:"select,omitempty" json:"select,omitempty"`
+	// Sensitive marks the value as containing secrets, which are redacted from
+	// debug output and error messages. Values decrypted using sops or age are
+	// always sensitive. Strings shorter than 4 characters aren't redacted.
+	Sensitive bool `yaml:"sensitive,omitempty" json:"sensitive,omitempty"`
 	// Format defines the format to parse the retrieved value as. Valid options
-	// are yaml or json.
-	Format string `yaml:"format" json:"format" jsonschema:"enum=yaml,enum=json,default=yaml"`
+	// are yaml, json, toml, hcl, ini, dotenv, properties, xml or csv, or the
+	// name of a format registered with generator.RegisterCodec.
+	Format string `yaml:"format" json:"format" jsonschema:"example=yaml,example=json,example=toml,example=hcl,example=ini,example=dotenv,example=properties,example=xml,example=csv,default=yaml"`
+}
+
+// GitSource reads files from a git repository at a specific revision.
+// Remote repositories are cloned into the cache directory, and are only
+// fetched again if the revision isn't a commit which is already cached.
+type GitSource struct {
+	// Repo is the URL of the repository, or the path of a local repository relative to this pipeline file.
+	Repo StringOrValue `yaml:"repo" json:"repo"`
+	// Ref is the branch, tag, commit or revision, such as HEAD~1, to read files at. Defaults to HEAD.
+	Ref StringOrValue `yaml:"ref,omitempty" json:"ref,omitempty"`
+	// Path is the path of a file within the repository to read, which is returned like a file value.
+	Path StringOrValue `yaml:"path,omitempty" json:"path,omitempty" jsonschema:"oneof_required=path"`
+	// Glob is a glob pattern within the repository, and the matching files are returned like a glob value.
+	Glob StringOrValue `yaml:"glob,omitempty" json:"glob,omitempty" jsonschema:"oneof_required=glob"`
+}
+
+// SOPSSource decrypts a file encrypted with SOPS using the sops command, and
+// returns the decrypted data parsed using the format of the file. The sops
+// binary isn't bundled with yamlforge, and must be installed and on the PATH.
+// Keys are found by sops, such as age keys from the SOPS_AGE_KEY_FILE or
+// SOPS_AGE_KEY environment variables, and PGP keys from the GnuPG keyring.
+type SOPSSource struct {
+	// File is the path of the encrypted file relative to this pipeline file.
+	File StringOrValue `yaml:"file" json:"file"`
+	// Format is the format of the encrypted file, one of yaml, json, dotenv or ini. Defaults to the extension of the file.
+	Format StringOrValue `yaml:"format,omitempty" json:"format,omitempty"`
+}
+
+// AgeSource decrypts a file encrypted with age, and returns the decrypted data
+// parsed using the format of the file name without the .age extension, or the
+// raw data if the format is unknown.
+type AgeSource struct {
+	// File is the path of the encrypted file relative to this pipeline file. Both binary and armored files are supported.
+	File StringOrValue `yaml:"file" json:"file"`
+	// Identities are age identity files, as generated by age-keygen, used to decrypt the file. Defaults to the identities in the file named by the SOPS_AGE_KEY_FILE environment variable, the SOPS_AGE_KEY environment variable, or sops/age/keys.txt in the user config directory.
+	Identities []Value `yaml:"identities,omitempty" json:"identities,omitempty"`
 }
 
 // NamedValue is a Value with a name.
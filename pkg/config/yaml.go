@@ -2,9 +2,17 @@ package config
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
 )
 
 var DefaultYAMLDecodeOptions = []yaml.DecodeOption{
@@ -46,3 +54,342 @@ func DecodeYAML(data []byte, v any) error {
 func EncodeYAML(v any) ([]byte, error) {
 	return yaml.MarshalWithOptions(v, DefaultYAMLEncodeOptions...)
 }
+
+// EncodeYAMLDocuments encodes docs as a stream of YAML documents, formatted
+// according to format.
+func EncodeYAMLDocuments(docs []any, format YAMLFormat) ([]byte, error) {
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	opts := append(NewYAMLEncodeOptions(format.Indent), yaml.UseSingleQuote(format.QuoteType == "single"))
+	enc := yaml.NewEncoder(&out, opts...)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return FormatYAML(out.Bytes(), format)
+}
+
+// FormatYAML formats a stream of YAML documents according to format,
+// retaining comments. The indent of data is not changed.
+func FormatYAML(data []byte, format YAMLFormat) ([]byte, error) {
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+	if (format == YAMLFormat{Indent: format.Indent}) {
+		return data, nil
+	}
+	file, err := parser.ParseBytes(data, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	f := yamlFormatter{format}
+	var out bytes.Buffer
+	for i, doc := range file.Docs {
+		if i > 0 || format.DocumentStart {
+			out.WriteString("---\n")
+		}
+		if doc.Body != nil {
+			body := f.format(doc.Body, scalarPosition{})
+			if s := body.String(); s != "" {
+				out.WriteString(s)
+				out.WriteString("\n")
+			}
+		}
+		if format.DocumentEnd {
+			out.WriteString("...\n")
+		}
+	}
+	return out.Bytes(), nil
+}
+
+func (format YAMLFormat) validate() error {
+	switch format.QuoteType {
+	case "", "double", "single":
+	default:
+		return fmt.Errorf("invalid quoteType %q, must be one of double or single", format.QuoteType)
+	}
+	switch format.MultilineStyle {
+	case "", "literal", "quoted":
+	default:
+		return fmt.Errorf("invalid multilineStyle %q, must be one of literal or quoted", format.MultilineStyle)
+	}
+	switch format.NullValue {
+	case "", "null", "~", "empty":
+	default:
+		return fmt.Errorf("invalid nullValue %q, must be one of null, ~ or empty", format.NullValue)
+	}
+	return nil
+}
+
+// scalarPosition describes where a node is written.
+type scalarPosition struct {
+	// prefix is the length of the line before the node.
+	prefix int
+	// indent is the indent of the lines of a block scalar.
+	indent int
+	// flow is true for nodes within flow style collections.
+	flow bool
+	// mappingValue is true for the values of mappings.
+	mappingValue bool
+}
+
+type yamlFormatter struct {
+	YAMLFormat
+}
+
+// format formats node, returning the node to replace it with.
+func (f yamlFormatter) format(node ast.Node, pos scalarPosition) ast.Node {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		if f.SortKeys {
+			sorted := make([]*ast.MappingValueNode, len(n.Values))
+			copy(sorted, n.Values)
+			sort.SliceStable(sorted, func(i, j int) bool {
+				return mappingKey(sorted[i]) < mappingKey(sorted[j])
+			})
+			// Keep the order of the keys if sorting them would move an alias
+			// before its anchor, which would make the document invalid.
+			if anchorsBeforeAliases(sorted) {
+				n.Values = sorted
+			}
+		}
+		for _, mv := range n.Values {
+			f.formatMappingValue(mv, pos.flow || n.IsFlowStyle)
+		}
+	case *ast.MappingValueNode:
+		f.formatMappingValue(n, pos.flow)
+	case *ast.SequenceNode:
+		column := n.Start.Position.Column - 1
+		for i, value := range n.Values {
+			n.Values[i] = f.format(value, scalarPosition{
+				prefix: column + 2,
+				indent: column + 2,
+				flow:   pos.flow || n.IsFlowStyle,
+			})
+		}
+		if f.useFlowStyle(n) {
+			n.IsFlowStyle = true
+		}
+	case *ast.AnchorNode:
+		n.Value = f.format(n.Value, pos)
+	case *ast.TagNode:
+		n.Value = f.format(n.Value, pos)
+	case *ast.StringNode:
+		return f.formatString(n, pos)
+	case *ast.LiteralNode:
+		if f.MultilineStyle == "quoted" && n.Comment == nil && !pos.flow {
+			tk := *n.Value.Token
+			tk.Type = token.DoubleQuoteType
+			return &ast.StringNode{BaseNode: &ast.BaseNode{}, Token: &tk, Value: n.Value.Value}
+		}
+	case *ast.NullNode:
+		if n.Comment != nil || f.NullValue == "" {
+			return n
+		}
+		value := f.NullValue
+		if value == "empty" {
+			if !pos.mappingValue {
+				return n
+			}
+			value = ""
+		}
+		return &nullNode{NullNode: n, value: value}
+	}
+	return node
+}
+
+// anchorsBeforeAliases returns true if the anchors defined within values are
+// defined before the aliases referring to them.
+func anchorsBeforeAliases(values []*ast.MappingValueNode) bool {
+	defined := make(map[string]int)
+	for i, mv := range values {
+		for _, node := range ast.Filter(ast.AnchorType, mv) {
+			defined[node.(*ast.AnchorNode).Name.GetToken().Value] = i
+		}
+	}
+	for i, mv := range values {
+		for _, node := range ast.Filter(ast.AliasType, mv) {
+			if j, ok := defined[node.(*ast.AliasNode).Value.GetToken().Value]; ok && j > i {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (f yamlFormatter) formatMappingValue(mv *ast.MappingValueNode, flow bool) {
+	if _, ok := mv.Key.(*ast.MergeKeyNode); ok {
+		return
+	}
+	column := mv.Key.GetToken().Position.Column - 1
+	mv.Value = f.format(mv.Value, scalarPosition{
+		prefix:       column + len(mv.Key.String()) + 2,
+		indent:       column + 2,
+		flow:         flow,
+		mappingValue: true,
+	})
+	if seq, ok := mv.Value.(*ast.SequenceNode); ok && seq.IsFlowStyle {
+		// Write flow style sequences on the same line as their key.
+		mv.IsFlowStyle = true
+	}
+}
+
+// useFlowStyle returns true if n should be converted to a flow style
+// sequence.
+func (f yamlFormatter) useFlowStyle(n *ast.SequenceNode) bool {
+	if n.IsFlowStyle || f.FlowSequences <= 0 || len(n.Values) == 0 || len(n.Values) > f.FlowSequences {
+		return false
+	}
+	if n.Comment != nil || n.FootComment != nil {
+		return false
+	}
+	for i, value := range n.Values {
+		if i < len(n.ValueHeadComments) && n.ValueHeadComments[i] != nil {
+			return false
+		}
+		if value.GetComment() != nil {
+			return false
+		}
+		switch v := value.(type) {
+		case *ast.StringNode:
+			// Plain strings can't contain flow indicators in flow style.
+			if v.Token.Type != token.SingleQuoteType && v.Token.Type != token.DoubleQuoteType && strings.ContainsAny(v.Value, ",[]{}#:\n") {
+				return false
+			}
+		case *ast.IntegerNode, *ast.FloatNode, *ast.BoolNode, *ast.NullNode, *ast.InfinityNode, *ast.NanNode:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (f yamlFormatter) formatString(n *ast.StringNode, pos scalarPosition) ast.Node {
+	if strings.Contains(n.Value, "\n") {
+		return n
+	}
+	value := n.Value
+	quoted := n.Token.Type == token.SingleQuoteType || n.Token.Type == token.DoubleQuoteType
+	if !quoted {
+		// The parser drops tabs from plain scalars, so read strings which
+		// need escaping from the source.
+		if origin := strings.Trim(n.Token.Origin, " \r\n"); !strings.ContainsAny(origin, "\r\n") && needsEscape(origin) {
+			value = origin
+		}
+	}
+	tokenType := n.Token.Type
+	if quoted || f.QuoteAll || needsEscape(value) {
+		switch {
+		case f.QuoteType == "single" && !needsEscape(value):
+			tokenType = token.SingleQuoteType
+		case f.QuoteType == "double" || !quoted || needsEscape(value):
+			tokenType = token.DoubleQuoteType
+		}
+	}
+	if tokenType != n.Token.Type || value != n.Value {
+		n = quoteString(n, value, tokenType)
+	}
+	if folded, ok := f.fold(n, pos); ok {
+		return folded
+	}
+	return n
+}
+
+// quoteString returns a copy of n with a new token writing value using the
+// quote style of tokenType, escaping it as needed.
+func quoteString(n *ast.StringNode, value string, tokenType token.Type) *ast.StringNode {
+	tk := *n.Token
+	tk.Type = tokenType
+	tk.Value = value
+	switch tokenType {
+	case token.SingleQuoteType:
+		tk.Origin = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case token.DoubleQuoteType:
+		tk.Origin = strconv.Quote(value)
+	default:
+		tk.Origin = value
+	}
+	return &ast.StringNode{BaseNode: n.BaseNode, Token: &tk, Value: value}
+}
+
+// fold returns a folded block scalar for strings longer than the line width.
+func (f yamlFormatter) fold(n *ast.StringNode, pos scalarPosition) (ast.Node, bool) {
+	if f.LineWidth <= 0 || pos.flow || n.Comment != nil || pos.prefix+len(n.String()) <= f.LineWidth {
+		return nil, false
+	}
+	// Line breaks in folded scalars are read as a single space, so only
+	// strings with single spaces between words can be folded.
+	if n.Value != strings.TrimSpace(n.Value) || strings.Contains(n.Value, "  ") || needsEscape(n.Value) {
+		return nil, false
+	}
+	width := f.LineWidth - pos.indent
+	var lines []string
+	var line string
+	for _, word := range strings.Split(n.Value, " ") {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	lines = append(lines, line)
+	if len(lines) == 1 {
+		return nil, false
+	}
+	indent := strings.Repeat(" ", pos.indent)
+	return &foldedNode{
+		StringNode: n,
+		text:       ">-\n" + indent + strings.Join(lines, "\n"+indent),
+	}, true
+}
+
+// needsEscape returns true if s contains characters which can only be
+// written in double quoted strings.
+func needsEscape(s string) bool {
+	for _, r := range s {
+		if r == '\t' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func mappingKey(mv *ast.MappingValueNode) string {
+	if _, ok := mv.Key.(*ast.MergeKeyNode); ok {
+		// Sort merge keys first, so keys after them override merged values.
+		return ""
+	}
+	if s, ok := mv.Key.(*ast.StringNode); ok {
+		return s.Value
+	}
+	return mv.Key.GetToken().Value
+}
+
+// foldedNode is a string written as a folded block scalar.
+type foldedNode struct {
+	*ast.StringNode
+	text string
+}
+
+func (n *foldedNode) String() string {
+	return n.text
+}
+
+// nullNode is a null written as value.
+type nullNode struct {
+	*ast.NullNode
+	value string
+}
+
+func (n *nullNode) String() string {
+	return n.value
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeYAMLDocuments(t *testing.T) {
+	docs := []any{
+		map[string]any{
+			"name":        "my-app",
+			"replicas":    3,
+			"version":     "1.0",
+			"args":        []any{"--verbose", "-v"},
+			"hosts":       []any{"a.example.com", "b.example.com", "c.example.com"},
+			"script":      "echo hello\necho world\n",
+			"annotations": nil,
+			"description": "A long description of the application which does not fit on a single line",
+		},
+		map[string]any{"kind": "Second"},
+	}
+	tests := []struct {
+		name   string
+		format YAMLFormat
+		want   string
+	}{
+		{
+			name:   "default",
+			format: YAMLFormat{Indent: 2},
+			want: `annotations: null
+args:
+  - --verbose
+  - -v
+description: A long description of the application which does not fit on a single line
+hosts:
+  - a.example.com
+  - b.example.com
+  - c.example.com
+name: my-app
+replicas: 3
+script: |
+  echo hello
+  echo world
+version: "1.0"
+---
+kind: Second
+`,
+		},
+		{
+			name: "all options",
+			format: YAMLFormat{
+				Indent:         2,
+				QuoteType:      "single",
+				QuoteAll:       true,
+				MultilineStyle: "quoted",
+				FlowSequences:  2,
+				DocumentStart:  true,
+				DocumentEnd:    true,
+				NullValue:      "~",
+				LineWidth:      60,
+			},
+			want: `---
+annotations: ~
+args: ['--verbose', '-v']
+description: >-
+  A long description of the application which does not fit
+  on a single line
+hosts:
+  - 'a.example.com'
+  - 'b.example.com'
+  - 'c.example.com'
+name: 'my-app'
+replicas: 3
+script: "echo hello\necho world\n"
+version: '1.0'
+...
+---
+kind: 'Second'
+...
+`,
+		},
+		{
+			name:   "empty null",
+			format: YAMLFormat{Indent: 2, NullValue: "empty"},
+			want: `annotations:
+args:
+  - --verbose
+  - -v
+description: A long description of the application which does not fit on a single line
+hosts:
+  - a.example.com
+  - b.example.com
+  - c.example.com
+name: my-app
+replicas: 3
+script: |
+  echo hello
+  echo world
+version: "1.0"
+---
+kind: Second
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := EncodeYAMLDocuments(docs, tt.format)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(out))
+
+			// The formatted output must decode to the same documents.
+			dec := NewYAMLDecoder(bytes.NewReader(out))
+			for _, want := range docs {
+				var got map[string]any
+				require.NoError(t, dec.Decode(&got))
+				wantJSON, gotJSON := mustJSON(t, want), mustJSON(t, got)
+				assert.JSONEq(t, wantJSON, gotJSON)
+			}
+		})
+	}
+}
+
+func TestFormatYAMLPreservesComments(t *testing.T) {
+	in := `# Settings.
+zone: b # the zone
+region: us-east-1
+tags: [a, b]
+ports:
+  - 80
+  - 443
+`
+	out, err := FormatYAML([]byte(in), YAMLFormat{SortKeys: true, FlowSequences: 2, QuoteType: "double", QuoteAll: true})
+	require.NoError(t, err)
+	assert.Equal(t, `ports: [80, 443]
+region: "us-east-1"
+tags: ["a", "b"]
+# Settings.
+zone: "b" # the zone
+`, string(out))
+}
+
+func TestFormatYAMLSortKeysAliases(t *testing.T) {
+	in := `zbase: &b
+  x: 1
+app: *b
+nested:
+  b: 2
+  a: 1
+`
+	out, err := FormatYAML([]byte(in), YAMLFormat{SortKeys: true})
+	require.NoError(t, err)
+	// The keys containing the anchor and alias keep their order, but nested
+	// mappings are still sorted.
+	assert.Equal(t, `zbase: &b
+  x: 1
+app: *b
+nested:
+  a: 1
+  b: 2
+`, string(out))
+
+	var decoded map[string]any
+	require.NoError(t, DecodeYAML(out, &decoded))
+	assert.Equal(t, decoded["zbase"], decoded["app"])
+}
+
+func TestEncodeYAMLDocumentsEscapes(t *testing.T) {
+	doc := map[string]any{"tab": "tab\there", "control": "nul\x01x", "quote": "it's"}
+	for _, format := range []YAMLFormat{
+		{Indent: 2, SortKeys: true},
+		{Indent: 2, QuoteAll: true},
+		{Indent: 2, QuoteAll: true, QuoteType: "single"},
+	} {
+		out, err := EncodeYAMLDocuments([]any{doc}, format)
+		require.NoError(t, err)
+		var decoded map[string]any
+		require.NoError(t, DecodeYAML(out, &decoded), string(out))
+		assert.Equal(t, doc, decoded, string(out))
+	}
+
+	out, err := EncodeYAMLDocuments([]any{doc}, YAMLFormat{Indent: 2, QuoteAll: true, QuoteType: "single"})
+	require.NoError(t, err)
+	assert.Equal(t, `control: "nul\x01x"
+quote: 'it''s'
+tab: "tab\there"
+`, string(out))
+}
+
+func TestFormatYAMLInvalid(t *testing.T) {
+	_, err := FormatYAML(nil, YAMLFormat{NullValue: "nil"})
+	assert.EqualError(t, err, `invalid nullValue "nil", must be one of null, ~ or empty`)
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/chancez/yamlforge/pkg/k8s"
+	"github.com/google/cel-go/cel"
+)
+
+var _ Generator = (*Assert)(nil)
+
+type Assert struct {
+	dir      string
+	cfg      config.AssertGenerator
+	refStore *Store
+}
+
+func NewAssert(dir string, cfg config.AssertGenerator, refStore *Store) *Assert {
+	return &Assert{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+type assertRule struct {
+	prg      cel.Program
+	message  string
+	severity string
+}
+
+func (a *Assert) Generate(ctx context.Context) (*Result, error) {
+	var rules []assertRule
+	for i, ruleCfg := range a.cfg.Rules {
+		expr, err := a.refStore.GetRawStringValue(ctx, a.dir, ruleCfg.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: error getting expression: %w", i, err)
+		}
+		prg, err := newCELProgram(expr, true)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: error creating CEL program: %w", i, err)
+		}
+		message, err := a.refStore.GetStringValue(ctx, a.dir, ruleCfg.Message)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: error getting message: %w", i, err)
+		}
+		if message == "" {
+			message = fmt.Sprintf("failed rule %q", expr)
+		}
+		severity, err := a.refStore.GetStringValue(ctx, a.dir, ruleCfg.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: error getting severity: %w", i, err)
+		}
+		switch severity {
+		case "":
+			severity = "error"
+		case "error", "warning":
+		default:
+			return nil, fmt.Errorf("rules[%d]: invalid severity %q, must be one of error or warning", i, severity)
+		}
+		rules = append(rules, assertRule{prg: prg, message: message, severity: severity})
+	}
+
+	variables := newCELVariables(a.refStore)
+	var errs []error
+	var output []any
+	for i, input := range a.cfg.Input {
+		vals, err := a.refStore.GetParsedValues(ctx, a.dir, input)
+		if err != nil {
+			return nil, fmt.Errorf("error getting value: %w", err)
+		}
+		source := fmt.Sprintf("input[%d] (%s)", i, describeValue(input))
+		docIndex := 0
+		for val, err := range vals {
+			if err != nil {
+				return nil, fmt.Errorf("%s: error while processing input: %w", source, err)
+			}
+			doc := val.Parsed()
+			output = append(output, doc)
+			docName := fmt.Sprintf("document[%d]", docIndex)
+			if obj, ok := doc.(map[string]any); ok {
+				if id, ok := k8s.GetResourceID(obj); ok {
+					docName = id.String()
+				}
+			}
+			docIndex++
+
+			for _, rule := range rules {
+				passed, err := evalAssertRule(ctx, rule.prg, variables.activation(doc))
+				if passed {
+					continue
+				}
+				msg := fmt.Sprintf("%s: %s: %s", source, docName, rule.message)
+				if err != nil {
+					msg = fmt.Sprintf("%s: %s", msg, err)
+				}
+				if rule.severity == "warning" {
+					fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
+					continue
+				}
+				errs = append(errs, errors.New(msg))
+			}
+		}
+	}
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("assertions failed:\n%w", errors.Join(errs...))
+	}
+
+	var out any = output
+	if len(output) == 1 {
+		out = output[0]
+	}
+	return &Result{Output: out}, nil
+}
+
+// evalAssertRule returns true if the document in activation passes the rule. Errors evaluating the
+// rule, such as accessing a missing field, fail the rule.
+func evalAssertRule(ctx context.Context, prg cel.Program, activation map[string]any) (bool, error) {
+	out, _, err := prg.ContextEval(ctx, activation)
+	if err != nil {
+		return false, fmt.Errorf("error evaluating rule: %s", err)
+	}
+	passed, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("rule returned %T, expected bool", out.Value())
+	}
+	return passed, nil
+}
@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssert(t *testing.T) {
+	store := NewStore(nil)
+	err := store.AddReference("manifests", &Result{
+		Format: "yaml",
+		Output: []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: good
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: bad
+  namespace: default
+---
+replicas: 1
+`),
+	})
+	require.NoError(t, err)
+
+	rule := func(expr, message, severity string) config.AssertRule {
+		r := config.AssertRule{
+			Expr:    config.StringOrValue{String: strPtr(expr)},
+			Message: config.StringOrValue{String: strPtr(message)},
+		}
+		if severity != "" {
+			r.Severity = config.StringOrValue{String: strPtr(severity)}
+		}
+		return r
+	}
+
+	_, err = NewAssert("", config.AssertGenerator{
+		Input: []config.Value{{Ref: "manifests"}},
+		Rules: []config.AssertRule{
+			rule("val.metadata.name != 'bad'", "name must not be bad", ""),
+			rule("has(val.kind)", "kind is recommended", "warning"),
+		},
+	}, store).Generate(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, `assertions failed:
+input[0] (ref "manifests"): v1 ConfigMap default/bad: name must not be bad
+input[0] (ref "manifests"): document[2]: name must not be bad: error evaluating rule: no such key: metadata`, err.Error())
+
+	res, err := NewAssert("", config.AssertGenerator{
+		Input: []config.Value{{Ref: "manifests"}},
+		Rules: []config.AssertRule{
+			rule("!has(val.kind) || val.kind == 'ConfigMap'", "only ConfigMaps are allowed", "error"),
+		},
+	}, store).Generate(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, res.Output, 3, "input should be returned unaltered")
+
+	_, err = NewAssert("", config.AssertGenerator{
+		Rules: []config.AssertRule{rule("true", "", "fatal")},
+	}, store).Generate(context.Background())
+	assert.ErrorContains(t, err, `invalid severity "fatal"`)
+}
@@ -0,0 +1,180 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// canonicalJSON encodes v as canonical JSON, as defined by the JSON
+// Canonicalization Scheme (RFC 8785).
+func canonicalJSON(v any) ([]byte, error) {
+	// Round trip v through encoding/json to normalize its types.
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var normalized any
+	if err := dec.Decode(&normalized); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := writeCanonicalJSON(&out, normalized); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func writeCanonicalJSON(out *bytes.Buffer, v any) error {
+	switch val := v.(type) {
+	case nil:
+		out.WriteString("null")
+	case bool:
+		out.WriteString(strconv.FormatBool(val))
+	case json.Number:
+		f, err := val.Float64()
+		if err != nil {
+			return fmt.Errorf("invalid number %s: %w", val, err)
+		}
+		s, err := formatCanonicalNumber(f)
+		if err != nil {
+			return err
+		}
+		out.WriteString(s)
+	case string:
+		writeCanonicalString(out, val)
+	case []any:
+		out.WriteByte('[')
+		for i, item := range val {
+			if i != 0 {
+				out.WriteByte(',')
+			}
+			if err := writeCanonicalJSON(out, item); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		// Keys are sorted by their UTF-16 code units.
+		sort.Slice(keys, func(i, j int) bool {
+			return compareUTF16(keys[i], keys[j]) < 0
+		})
+		out.WriteByte('{')
+		for i, k := range keys {
+			if i != 0 {
+				out.WriteByte(',')
+			}
+			writeCanonicalString(out, k)
+			out.WriteByte(':')
+			if err := writeCanonicalJSON(out, val[k]); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	default:
+		return fmt.Errorf("unsupported type %T", v)
+	}
+	return nil
+}
+
+// writeCanonicalString writes s as a JSON string, only escaping the
+// characters which must be escaped.
+func writeCanonicalString(out *bytes.Buffer, s string) {
+	out.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\b':
+			out.WriteString(`\b`)
+		case '\f':
+			out.WriteString(`\f`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '\t':
+			out.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(out, `\u%04x`, r)
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	}
+	out.WriteByte('"')
+}
+
+// formatCanonicalNumber formats f the same way as ECMAScript's
+// Number.prototype.toString.
+func formatCanonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("invalid number %v", f)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+	// Get the shortest digits which represent f, and its exponent.
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, err := strconv.Atoi(exp)
+	if err != nil {
+		return "", err
+	}
+	// n is the position of the decimal point relative to the digits.
+	n := e + 1
+	k := len(digits)
+	var s string
+	switch {
+	case k <= n && n <= 21:
+		s = digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		s = digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		s = "0." + strings.Repeat("0", -n) + digits
+	default:
+		s = digits[:1]
+		if k > 1 {
+			s += "." + digits[1:]
+		}
+		if n-1 >= 0 {
+			s += "e+" + strconv.Itoa(n-1)
+		} else {
+			s += "e-" + strconv.Itoa(1-n)
+		}
+	}
+	return sign + s, nil
+}
+
+func compareUTF16(a, b string) int {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			if ua[i] < ub[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(ua) - len(ub)
+}
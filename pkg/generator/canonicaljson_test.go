@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	// Example from RFC 8785, section 3.2.2.
+	input := `{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(input), &v))
+	out, err := canonicalJSON(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`, string(out))
+
+	// Keys are sorted by UTF-16 code units, and HTML is not escaped.
+	out, err = canonicalJSON(map[string]any{"\U0001F600": 1, "\uFB33": 2, "<a>": uint64(3)})
+	require.NoError(t, err)
+	assert.Equal(t, "{\"<a>\":3,\"\U0001F600\":1,\"\uFB33\":2}", string(out))
+}
+
+func TestFormatCanonicalNumber(t *testing.T) {
+	tests := map[float64]string{
+		0:                      "0",
+		-1:                     "-1",
+		1e21:                   "1e+21",
+		1e20:                   "100000000000000000000",
+		295147905179352825856:  "295147905179352830000",
+		9007199254740992:       "9007199254740992",
+		1.7976931348623157e308: "1.7976931348623157e+308",
+		5e-324:                 "5e-324",
+		0.000001:               "0.000001",
+		0.0000001:              "1e-7",
+		-1.5e-10:               "-1.5e-10",
+		123.456:                "123.456",
+	}
+	for f, want := range tests {
+		got, err := formatCanonicalNumber(f)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "formatting %v", f)
+	}
+}
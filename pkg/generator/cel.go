@@ -2,11 +2,17 @@ package generator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 
+	"github.com/chancez/yamlforge/pkg/cellib"
 	"github.com/chancez/yamlforge/pkg/config"
 	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
 )
 
 var _ Generator = (*CEL)(nil)
@@ -30,84 +36,217 @@ func NewCEL(dir string, cfg config.CELGenerator, refStore *Store) *CEL {
 }
 
 func (c *CEL) Generate(ctx context.Context) (*Result, error) {
-	expr, err := c.refStore.GetStringValue(c.dir, c.cfg.Expr)
+	expr, err := c.refStore.GetRawStringValue(ctx, c.dir, c.cfg.Expr)
 	if err != nil {
 		return nil, fmt.Errorf("error getting expression: %w", err)
 	}
-	filter, err := c.refStore.GetBoolValue(c.dir, c.cfg.Filter)
+	filter, err := c.refStore.GetBoolValue(ctx, c.dir, c.cfg.Filter)
 	if err != nil {
 		return nil, fmt.Errorf("error getting filter: %w", err)
 	}
+	flatMap, err := c.refStore.GetBoolValue(ctx, c.dir, c.cfg.FlatMap)
+	if err != nil {
+		return nil, fmt.Errorf("error getting flatMap: %w", err)
+	}
+	groupBy, err := c.refStore.GetBoolValue(ctx, c.dir, c.cfg.GroupBy)
+	if err != nil {
+		return nil, fmt.Errorf("error getting groupBy: %w", err)
+	}
+	reduce, err := c.refStore.GetBoolValue(ctx, c.dir, c.cfg.Reduce)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reduce: %w", err)
+	}
+	modes := 0
+	for _, enabled := range []bool{filter, flatMap, groupBy, reduce} {
+		if enabled {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return nil, errors.New("only one of filter, flatMap, groupBy or reduce can be set")
+	}
+	if (groupBy || reduce) && c.cfg.Input == nil {
+		return nil, errors.New("input is required when groupBy or reduce is set")
+	}
 
-	prg, err := c.newProgram(expr, filter)
+	var opts []cel.EnvOption
+	if reduce {
+		opts = append(opts, cel.Variable("acc", cel.DynType))
+	}
+	prg, err := newCELProgram(expr, filter, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("error creating CEL program: %w", err)
 	}
+	variables := newCELVariables(c.refStore)
 
-	var results []any
-	if c.cfg.Input != nil {
-		vals, err := c.refStore.GetParsedValues(c.dir, *c.cfg.Input)
-		if err != nil {
-			return nil, fmt.Errorf("error getting input: %w", err)
-		}
-		invertFilter, err := c.refStore.GetBoolValue(c.dir, c.cfg.InvertFilter)
-		if err != nil {
-			return nil, fmt.Errorf("error getting invertFilter: %w", err)
-		}
-		collect, err := c.refStore.GetBoolValue(c.dir, c.cfg.Collect)
+	if c.cfg.Input == nil {
+		// No input, just evaluate once without 'val' set
+		out, _, err := prg.ContextEval(ctx, variables.activation(nil))
 		if err != nil {
-			return nil, fmt.Errorf("error getting collect: %w", err)
+			return nil, fmt.Errorf("error evaluating CEL program: %s", err)
 		}
-
-		if collect {
-			var allVals []any
-			for val, err := range vals {
-				if err != nil {
-					return nil, fmt.Errorf("error while processing input: %w", err)
-				}
-				allVals = append(allVals, val.Parsed())
-			}
-			vals = func(yield func(ParsedValue, error) bool) {
-				yield(ParsedValue{parsed: allVals}, nil)
+		if flatMap {
+			results, err := flattenCELList(out)
+			if err != nil {
+				return nil, err
 			}
+			return &Result{Output: celResults(results)}, nil
 		}
+		return &Result{Output: cellib.ToNative(out)}, nil
+	}
+
+	vals, err := c.refStore.GetParsedValues(ctx, c.dir, *c.cfg.Input)
+	if err != nil {
+		return nil, fmt.Errorf("error getting input: %w", err)
+	}
+	invertFilter, err := c.refStore.GetBoolValue(ctx, c.dir, c.cfg.InvertFilter)
+	if err != nil {
+		return nil, fmt.Errorf("error getting invertFilter: %w", err)
+	}
+	collect, err := c.refStore.GetBoolValue(ctx, c.dir, c.cfg.Collect)
+	if err != nil {
+		return nil, fmt.Errorf("error getting collect: %w", err)
+	}
 
+	if collect {
+		var allVals []any
 		for val, err := range vals {
 			if err != nil {
 				return nil, fmt.Errorf("error while processing input: %w", err)
 			}
-			result, skip, err := c.evalProgram(ctx, prg, val.Parsed(), filter, invertFilter)
+			allVals = append(allVals, val.Parsed())
+		}
+		vals = func(yield func(ParsedValue, error) bool) {
+			yield(ParsedValue{parsed: allVals}, nil)
+		}
+	}
+
+	var acc any
+	if reduce && c.cfg.Initial != nil {
+		acc, err = c.getInitial(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	groups := make(map[string]any)
+
+	var results []any
+	for val, err := range vals {
+		if err != nil {
+			return nil, fmt.Errorf("error while processing input: %w", err)
+		}
+		switch {
+		case filter:
+			result, skip, err := c.evalProgram(ctx, prg, variables, val.Parsed(), filter, invertFilter)
 			if err != nil {
 				return nil, err
 			}
-			if filter && skip {
+			if skip {
 				continue
 			}
 			results = append(results, result)
+		case flatMap:
+			out, _, err := prg.ContextEval(ctx, variables.activation(val.Parsed()))
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating CEL program: %s", err)
+			}
+			items, err := flattenCELList(out)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, items...)
+		case groupBy:
+			out, _, err := prg.ContextEval(ctx, variables.activation(val.Parsed()))
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating CEL program: %s", err)
+			}
+			key, ok := out.Value().(string)
+			if !ok {
+				key = fmt.Sprint(cellib.ToNative(out))
+			}
+			group, _ := groups[key].([]any)
+			groups[key] = append(group, val.Parsed())
+		case reduce:
+			activation := variables.activation(val.Parsed())
+			activation["acc"] = acc
+			out, _, err := prg.ContextEval(ctx, activation)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating CEL program: %s", err)
+			}
+			acc = cellib.ToNative(out)
+		default:
+			result, _, err := c.evalProgram(ctx, prg, variables, val.Parsed(), false, false)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, result)
 		}
-	} else {
-		// No input, just evaluate once with no variables
-		out, _, err := prg.ContextEval(ctx, map[string]any{})
+	}
+
+	switch {
+	case groupBy:
+		return &Result{Output: groups}, nil
+	case reduce:
+		return &Result{Output: acc}, nil
+	default:
+		return &Result{Output: celResults(results)}, nil
+	}
+}
+
+// getInitial returns the parsed initial value of the accumulator for reduce.
+func (c *CEL) getInitial(ctx context.Context) (any, error) {
+	res, err := c.refStore.GetAnyValue(ctx, c.dir, *c.cfg.Initial)
+	if err != nil {
+		return nil, fmt.Errorf("error getting initial: %w", err)
+	}
+	if res == nil {
+		return nil, nil
+	}
+	switch res.Output.(type) {
+	case string, []byte:
+	default:
+		// Already parsed, use it as is rather than splitting lists into
+		// documents.
+		return normalizeNumbers(res.Output), nil
+	}
+	docs, err := parseResult(res, "yaml")
+	if err != nil {
+		return nil, fmt.Errorf("error parsing initial: %w", err)
+	}
+	for doc, err := range docs {
 		if err != nil {
-			return nil, fmt.Errorf("error evaluating CEL program: %s", err)
+			return nil, fmt.Errorf("error parsing initial: %w", err)
 		}
-		result := out.Value()
-		results = append(results, result)
+		return normalizeNumbers(doc.Parsed()), nil
 	}
+	return nil, nil
+}
 
-	var output any
+// celResults returns the output for a list of results, unwrapping it if there
+// is a single result.
+func celResults(results []any) any {
 	if len(results) == 1 {
-		output = results[0]
-	} else {
-		output = results
+		return results[0]
 	}
-	return &Result{Output: output}, nil
+	return results
 }
 
-func (c *CEL) newProgram(expr string, filter bool) (cel.Program, error) {
-	env, err := cel.NewEnv(
-		cel.Variable("val", cel.DynType),
-		cel.OptionalTypes(),
+// flattenCELList returns the items of a CEL list as separate Go values.
+func flattenCELList(val ref.Val) ([]any, error) {
+	list, ok := val.(traits.Lister)
+	if !ok {
+		return nil, fmt.Errorf("flatMap expression must return a list, got %s", val.Type().TypeName())
+	}
+	native, _ := cellib.ToNative(list).([]any)
+	return native, nil
+}
+
+// newCELProgram compiles expr with the input set to the variable 'val'. If
+// boolResult is true, the expression must return a boolean. opts declare any
+// additional variables.
+func newCELProgram(expr string, boolResult bool, opts ...cel.EnvOption) (cel.Program, error) {
+	env, err := cellib.NewEnv(
+		append([]cel.EnvOption{cel.Variable("val", cel.DynType)}, opts...)...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error creating CEL environment: %w", err)
@@ -122,7 +261,7 @@ func (c *CEL) newProgram(expr string, filter bool) (cel.Program, error) {
 		return nil, fmt.Errorf("CEL type-check error: %s", iss.Err())
 	}
 
-	if filter && checked.OutputType() != cel.BoolType {
+	if boolResult && checked.OutputType() != cel.BoolType {
 		return nil, fmt.Errorf("CEL expression has invalid result type: got %q, wanted %q", checked.OutputType(), cel.BoolType)
 	}
 
@@ -133,15 +272,47 @@ func (c *CEL) newProgram(expr string, filter bool) (cel.Program, error) {
 	return prg, nil
 }
 
-func (c *CEL) evalProgram(ctx context.Context, prg cel.Program, inputVal any, filter, invertFilter bool) (outVal any, matched bool, error error) {
-	out, _, err := prg.ContextEval(ctx, map[string]any{
-		"val": inputVal,
+// celVariables provides the variables used to evaluate CEL programs. refs is
+// only computed if a program uses it, since every reference must be parsed,
+// and is then shared by each evaluation.
+type celVariables struct {
+	store *Store
+	once  sync.Once
+	refs  ref.Val
+}
+
+func newCELVariables(store *Store) *celVariables {
+	return &celVariables{store: store}
+}
+
+func (v *celVariables) getRefs() ref.Val {
+	v.once.Do(func() {
+		refs, err := v.store.parsedReferences()
+		if err != nil {
+			v.refs = types.NewErrFromString(fmt.Sprintf("error getting refs: %s", err))
+			return
+		}
+		v.refs = types.DefaultTypeAdapter.NativeToValue(refs)
 	})
+	return v.refs
+}
+
+// activation returns the variables with the input set to val.
+func (v *celVariables) activation(val any) map[string]any {
+	return map[string]any{
+		"val":  val,
+		"vars": v.store.vars,
+		"refs": v.getRefs,
+	}
+}
+
+func (c *CEL) evalProgram(ctx context.Context, prg cel.Program, variables *celVariables, inputVal any, filter, invertFilter bool) (outVal any, matched bool, error error) {
+	out, _, err := prg.ContextEval(ctx, variables.activation(inputVal))
 	if err != nil {
 		return nil, false, fmt.Errorf("error evaluating CEL program: %s", err)
 	}
 
-	outVal = out.Value()
+	outVal = cellib.ToNative(out)
 	if filter {
 		outVal = inputVal
 		v, err := out.ConvertToNative(goBoolType)
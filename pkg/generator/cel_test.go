@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCELModes(t *testing.T) {
+	store := NewStore(nil)
+	err := store.AddReference("docs", &Result{
+		Format: "yaml",
+		Output: []byte(`
+kind: A
+items: [1, 2]
+---
+kind: B
+items: [3]
+---
+kind: A
+items: []
+`),
+	})
+	require.NoError(t, err)
+
+	generate := func(cfg config.CELGenerator) (*Result, error) {
+		cfg.Input = &config.Value{Ref: "docs"}
+		return NewCEL("", cfg, store).Generate(context.Background())
+	}
+	enabled := config.BoolOrValue{Bool: boolPtr(true)}
+
+	res, err := generate(config.CELGenerator{
+		Expr:    config.StringOrValue{String: strPtr("val.items.map(i, {'kind': val.kind, 'item': i})")},
+		FlatMap: enabled,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		map[string]any{"kind": "A", "item": uint64(1)},
+		map[string]any{"kind": "A", "item": uint64(2)},
+		map[string]any{"kind": "B", "item": uint64(3)},
+	}, res.Output)
+
+	res, err = generate(config.CELGenerator{
+		Expr:    config.StringOrValue{String: strPtr("val.kind")},
+		GroupBy: enabled,
+	})
+	require.NoError(t, err)
+	groups := res.Output.(map[string]any)
+	assert.Len(t, groups["A"], 2)
+	assert.Len(t, groups["B"], 1)
+
+	var initial any = map[string]any{"total": int64(0)}
+	res, err = generate(config.CELGenerator{
+		Expr:    config.StringOrValue{String: strPtr("{'total': acc.total + size(val.items)}")},
+		Reduce:  enabled,
+		Initial: &config.AnyOrValue{Any: &initial},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"total": int64(3)}, res.Output)
+
+	_, err = generate(config.CELGenerator{
+		Expr:    config.StringOrValue{String: strPtr("val")},
+		FlatMap: enabled,
+	})
+	assert.ErrorContains(t, err, "flatMap expression must return a list")
+
+	_, err = generate(config.CELGenerator{
+		Expr:    config.StringOrValue{String: strPtr("val.kind")},
+		GroupBy: enabled,
+		Reduce:  enabled,
+	})
+	assert.ErrorContains(t, err, "only one of filter, flatMap, groupBy or reduce can be set")
+}
+
+func TestCELReduceDecodedInitial(t *testing.T) {
+	store := NewStore(nil)
+	err := store.AddReference("docs", &Result{
+		Format: "yaml",
+		Output: []byte("items: [1, 2]\n---\nitems: [3]\n"),
+	})
+	require.NoError(t, err)
+
+	// Numbers in the configuration are decoded as float64, and numbers in YAML
+	// values as uint64, which CEL can't add to an int.
+	for _, initial := range []string{`initial: 0`, `initial: {value: "0"}`} {
+		var cfg config.CELGenerator
+		err := config.DecodeYAML([]byte("expr: acc + size(val.items)\nreduce: true\n"+initial+"\ninput: {ref: docs}\n"), &cfg)
+		require.NoError(t, err)
+		res, err := NewCEL("", cfg, store).Generate(context.Background())
+		require.NoError(t, err, initial)
+		assert.Equal(t, int64(3), res.Output, initial)
+	}
+}
@@ -0,0 +1,287 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/chancez/yamlforge/pkg/k8s"
+)
+
+var (
+	_ Generator = (*ConfigMap)(nil)
+	_ Generator = (*Secret)(nil)
+)
+
+// configKeyPattern matches the valid keys of the data of ConfigMaps and
+// Secrets.
+var configKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+type ConfigMap struct {
+	dir      string
+	cfg      config.ConfigMapGenerator
+	refStore *Store
+}
+
+func NewConfigMap(dir string, cfg config.ConfigMapGenerator, refStore *Store) *ConfigMap {
+	return &ConfigMap{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+func (cm *ConfigMap) Generate(ctx context.Context) (*Result, error) {
+	return generateConfigObject(ctx, cm.dir, cm.cfg, cm.refStore, "ConfigMap", func(obj map[string]any, data map[string][]byte) {
+		stringData := make(map[string]any)
+		binaryData := make(map[string]any)
+		for key, val := range data {
+			if utf8.Valid(val) {
+				stringData[key] = string(val)
+			} else {
+				binaryData[key] = base64.StdEncoding.EncodeToString(val)
+			}
+		}
+		if len(stringData) != 0 {
+			obj["data"] = stringData
+		}
+		if len(binaryData) != 0 {
+			obj["binaryData"] = binaryData
+		}
+	})
+}
+
+type Secret struct {
+	dir      string
+	cfg      config.SecretGenerator
+	refStore *Store
+}
+
+func NewSecret(dir string, cfg config.SecretGenerator, refStore *Store) *Secret {
+	return &Secret{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+func (s *Secret) Generate(ctx context.Context) (*Result, error) {
+	secretType, err := s.refStore.GetStringValue(ctx, s.dir, s.cfg.Type)
+	if err != nil {
+		return nil, fmt.Errorf("error getting type: %w", err)
+	}
+	if secretType == "" {
+		secretType = "Opaque"
+	}
+	return generateConfigObject(ctx, s.dir, s.cfg.ConfigMapGenerator, s.refStore, "Secret", func(obj map[string]any, data map[string][]byte) {
+		encoded := make(map[string]any)
+		for key, val := range data {
+			encoded[key] = base64.StdEncoding.EncodeToString(val)
+		}
+		if len(encoded) != 0 {
+			obj["data"] = encoded
+		}
+		obj["type"] = secretType
+	})
+}
+
+// generateConfigObject builds a ConfigMap or Secret from cfg, using setData to
+// populate the object with its data. References to the object in the
+// configured resources are updated to the generated name.
+func generateConfigObject(ctx context.Context, dir string, cfg config.ConfigMapGenerator, refStore *Store, kind string, setData func(obj map[string]any, data map[string][]byte)) (*Result, error) {
+	name, err := refStore.GetStringValue(ctx, dir, cfg.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting name: %w", err)
+	}
+	if name == "" {
+		return nil, errors.New("name cannot be empty")
+	}
+	namespace, err := refStore.GetStringValue(ctx, dir, cfg.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting namespace: %w", err)
+	}
+	labels, err := refStore.GetMapValue(ctx, dir, cfg.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("error getting labels: %w", err)
+	}
+	annotations, err := refStore.GetMapValue(ctx, dir, cfg.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("error getting annotations: %w", err)
+	}
+	disableNameSuffixHash, err := refStore.GetBoolValue(ctx, dir, cfg.DisableNameSuffixHash)
+	if err != nil {
+		return nil, fmt.Errorf("error getting disableNameSuffixHash: %w", err)
+	}
+
+	data := make(map[string][]byte)
+	addData := func(key string, val []byte) error {
+		if !configKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid key %q, must consist of alphanumeric characters, '-', '_' or '.'", key)
+		}
+		if _, exists := data[key]; exists {
+			return fmt.Errorf("duplicate key %q", key)
+		}
+		data[key] = val
+		return nil
+	}
+	for i, item := range cfg.Data {
+		key := item.Name
+		if key == "" && item.File != "" {
+			file, err := refStore.interpolateString(item.File)
+			if err != nil {
+				return nil, fmt.Errorf("data[%d]: error interpolating file: %w", i, err)
+			}
+			key = filepath.Base(file)
+		}
+		if key == "" && item.AsMap {
+			// Add each file read by glob or dir using its relative path.
+			res, err := refStore.GetValue(ctx, dir, item.Value)
+			if err != nil {
+				return nil, fmt.Errorf("data[%d]: error getting value: %w", i, err)
+			}
+			files, ok := res.Output.(map[string]any)
+			if !ok && res.Output != nil {
+				return nil, fmt.Errorf("data[%d]: expected a map of files, got %T", i, res.Output)
+			}
+			// Add the files in order so errors are consistent.
+			names := make([]string, 0, len(files))
+			for name := range files {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			for _, name := range names {
+				val, err := ConvertToBytes(&Result{Output: files[name]})
+				if err != nil {
+					return nil, fmt.Errorf("data[%d]: error getting value: %w", i, err)
+				}
+				if err := addData(name, val); err != nil {
+					return nil, fmt.Errorf("data[%d]: %w", i, err)
+				}
+			}
+			continue
+		}
+		if key == "" {
+			return nil, fmt.Errorf("data[%d]: name cannot be empty", i)
+		}
+		val, err := refStore.GetValueBytes(ctx, dir, item.Value)
+		if err != nil {
+			return nil, fmt.Errorf("data[%d]: error getting value: %w", i, err)
+		}
+		if err := addData(key, val); err != nil {
+			return nil, fmt.Errorf("data[%d]: %w", i, err)
+		}
+	}
+	patterns, err := refStore.GetStringValueList(ctx, dir, cfg.Files)
+	if err != nil {
+		return nil, fmt.Errorf("error getting files: %w", err)
+	}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("files: invalid pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("files: pattern %q matched no files", pattern)
+		}
+		for _, match := range matches {
+			val, err := os.ReadFile(match)
+			if err != nil {
+				return nil, fmt.Errorf("files: error reading %q: %w", match, err)
+			}
+			if err := addData(filepath.Base(match), val); err != nil {
+				return nil, fmt.Errorf("files: %w", err)
+			}
+		}
+	}
+
+	metadata := map[string]any{
+		"name": name,
+	}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	if len(labels) != 0 {
+		metadata["labels"] = labels
+	}
+	if len(annotations) != 0 {
+		metadata["annotations"] = annotations
+	}
+	obj := map[string]any{
+		"apiVersion": "v1",
+		"kind":       kind,
+		"metadata":   metadata,
+	}
+	setData(obj, data)
+
+	if !disableNameSuffixHash {
+		hash, err := contentHash(obj)
+		if err != nil {
+			return nil, fmt.Errorf("error computing content hash: %w", err)
+		}
+		metadata["name"] = name + "-" + hash
+	}
+	newName := metadata["name"].(string)
+
+	docs := []any{obj}
+	for _, input := range cfg.Resources {
+		vals, err := refStore.GetParsedValues(ctx, dir, input)
+		if err != nil {
+			return nil, fmt.Errorf("error getting resources: %w", err)
+		}
+		for val, err := range vals {
+			if err != nil {
+				return nil, fmt.Errorf("error while processing resources: %w", err)
+			}
+			if val.Parsed() == nil {
+				continue
+			}
+			// Copy the input to avoid modifying the output of other stages.
+			doc := deepCopy(val.Parsed())
+			if resource, ok := doc.(map[string]any); ok {
+				resourceNamespace, _ := k8s.NestedMap(resource, "metadata")["namespace"].(string)
+				if namespace == "" || resourceNamespace == "" || resourceNamespace == namespace {
+					k8s.UpdateReferences(resource, func(refKind, refName string) string {
+						if refKind == kind && refName == name {
+							return newName
+						}
+						return refName
+					})
+				}
+			}
+			docs = append(docs, doc)
+		}
+	}
+
+	if len(docs) == 1 {
+		return &Result{Output: obj}, nil
+	}
+	return &Result{Output: docs}, nil
+}
+
+// contentHash returns a short hash of the kind, data and type of obj, which
+// changes whenever its content changes.
+func contentHash(obj map[string]any) (string, error) {
+	content := make(map[string]any)
+	for _, key := range []string{"kind", "data", "binaryData", "type"} {
+		if val, ok := obj[key]; ok {
+			content[key] = val
+		}
+	}
+	// encoding/json sorts map keys, so the encoding is stable.
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:10], nil
+}
@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigMap(t *testing.T) {
+	name := "app"
+	literal := func(key string, val any) config.NamedValue {
+		return config.NamedValue{Name: key, Value: config.Value{Value: &config.AnyOrValue{Any: &val}}}
+	}
+	generate := func(data ...config.NamedValue) (map[string]any, error) {
+		res, err := NewConfigMap("", config.ConfigMapGenerator{
+			Name: config.StringOrValue{String: &name},
+			Data: data,
+		}, NewStore(nil)).Generate(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		return res.Output.(map[string]any), nil
+	}
+
+	obj, err := generate(literal("app.env", "PORT=8080\n"), literal("logo.png", string([]byte{0x89, 'P', 'N', 'G', 0xff})))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"app.env": "PORT=8080\n"}, obj["data"])
+	// Data which isn't valid UTF-8 is base64 encoded into binaryData.
+	assert.Equal(t, map[string]any{"logo.png": base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G', 0xff})}, obj["binaryData"])
+
+	// A hash of the content is appended to the name, which changes with the
+	// content.
+	metadata := obj["metadata"].(map[string]any)
+	assert.Regexp(t, `^app-[0-9a-f]{10}$`, metadata["name"])
+	other, err := generate(literal("app.env", "PORT=9090\n"))
+	require.NoError(t, err)
+	assert.NotEqual(t, metadata["name"], other["metadata"].(map[string]any)["name"])
+	same, err := generate(literal("app.env", "PORT=8080\n"), literal("logo.png", string([]byte{0x89, 'P', 'N', 'G', 0xff})))
+	require.NoError(t, err)
+	assert.Equal(t, metadata["name"], same["metadata"].(map[string]any)["name"])
+
+	_, err = generate(literal("app.env", "a"), literal("app.env", "b"))
+	assert.EqualError(t, err, `data[1]: duplicate key "app.env"`)
+
+	_, err = generate(literal("config/app.env", "a"))
+	assert.EqualError(t, err, `data[0]: invalid key "config/app.env", must consist of alphanumeric characters, '-', '_' or '.'`)
+}
+
+func TestSecret(t *testing.T) {
+	name := "creds"
+	disable := true
+	password := any("hunter2")
+	res, err := NewSecret("", config.SecretGenerator{
+		ConfigMapGenerator: config.ConfigMapGenerator{
+			Name:                  config.StringOrValue{String: &name},
+			DisableNameSuffixHash: config.BoolOrValue{Bool: &disable},
+			Data: []config.NamedValue{
+				{Name: "password", Value: config.Value{Value: &config.AnyOrValue{Any: &password}}},
+			},
+		},
+	}, NewStore(nil)).Generate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]any{"name": "creds"},
+		"type":       "Opaque",
+		"data":       map[string]any{"password": base64.StdEncoding.EncodeToString([]byte("hunter2"))},
+	}, res.Output)
+}
+
+func TestConfigMapDirKeys(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "dashboards", "team"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dashboards", "a.json"), []byte(`{}`), 0o644))
+
+	cfg := config.ConfigMapGenerator{
+		Name:                  config.StringOrValue{String: strPtr("dashboards")},
+		DisableNameSuffixHash: config.BoolOrValue{Bool: boolPtr(true)},
+		Data: []config.NamedValue{
+			{Value: config.Value{Dir: "dashboards", AsMap: true}},
+		},
+	}
+	res, err := NewConfigMap(dir, cfg, NewStore(nil)).Generate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a.json": "{}"}, res.Output.(map[string]any)["data"])
+
+	// Files in subdirectories are keyed by their relative path, which isn't a
+	// valid key.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dashboards", "team", "x.json"), []byte(`{}`), 0o644))
+	_, err = NewConfigMap(dir, cfg, NewStore(nil)).Generate(context.Background())
+	assert.EqualError(t, err, `data[0]: invalid key "team/x.json", must consist of alphanumeric characters, '-', '_' or '.'`)
+}
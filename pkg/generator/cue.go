@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	cueerrors "cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/load"
+	"github.com/chancez/yamlforge/pkg/config"
+)
+
+var _ Generator = (*CUE)(nil)
+
+type CUE struct {
+	dir      string
+	cfg      config.CUEGenerator
+	refStore *Store
+}
+
+func NewCUE(dir string, cfg config.CUEGenerator, refStore *Store) *CUE {
+	return &CUE{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+func (c *CUE) Generate(ctx context.Context) (*Result, error) {
+	paths, err := c.refStore.GetStringValueList(ctx, c.dir, c.cfg.Paths)
+	if err != nil {
+		return nil, fmt.Errorf("error getting paths: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("paths cannot be empty")
+	}
+	expression, err := c.refStore.GetRawStringValue(ctx, c.dir, c.cfg.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("error getting expression: %w", err)
+	}
+	stream, err := c.refStore.GetBoolValue(ctx, c.dir, c.cfg.Stream)
+	if err != nil {
+		return nil, fmt.Errorf("error getting stream: %w", err)
+	}
+	dir, err := filepath.Abs(c.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error getting directory: %w", err)
+	}
+
+	cueCtx := cuecontext.New()
+	val := cueCtx.CompileString("_")
+	for _, inst := range load.Instances(paths, &load.Config{Dir: dir}) {
+		if inst.Err != nil {
+			return nil, fmt.Errorf("error loading CUE: %s", cueErrorDetails(inst.Err))
+		}
+		instVal := cueCtx.BuildInstance(inst)
+		if instVal.Err() != nil {
+			return nil, fmt.Errorf("error building CUE: %s", cueErrorDetails(instVal.Err()))
+		}
+		val = val.Unify(instVal)
+	}
+
+	for i, input := range c.cfg.Input {
+		res, err := c.refStore.GetValue(ctx, c.dir, input.Value)
+		if err != nil {
+			return nil, fmt.Errorf("input[%d]: error getting value: %w", i, err)
+		}
+		data, err := parseReference(res)
+		if err != nil {
+			return nil, fmt.Errorf("input[%d]: error parsing value: %w", i, err)
+		}
+		path, err := c.refStore.GetStringValue(ctx, c.dir, input.Path)
+		if err != nil {
+			return nil, fmt.Errorf("input[%d]: error getting path: %w", i, err)
+		}
+		// Integral floats, such as the numbers of 'value' sources, would
+		// conflict with int constraints.
+		encoded := cueCtx.Encode(normalizeNumbers(data))
+		if encoded.Err() != nil {
+			return nil, fmt.Errorf("input[%d]: error converting value to CUE: %s", i, cueErrorDetails(encoded.Err()))
+		}
+		if path == "" {
+			val = val.Unify(encoded)
+		} else {
+			val = val.FillPath(cue.ParsePath(path), encoded)
+		}
+	}
+
+	if expression != "" {
+		val = val.LookupPath(cue.ParsePath(expression))
+		if !val.Exists() {
+			return nil, fmt.Errorf("expression %q not found", expression)
+		}
+	}
+	if err := val.Validate(cue.Concrete(true)); err != nil {
+		return nil, fmt.Errorf("CUE validation failed:\n%s", cueErrorDetails(err))
+	}
+
+	var out any
+	if err := val.Decode(&out); err != nil {
+		return nil, fmt.Errorf("error exporting CUE value: %s", cueErrorDetails(err))
+	}
+	if stream {
+		docs, ok := out.([]any)
+		if !ok {
+			return nil, errors.New("stream is set, but the exported value is not a list")
+		}
+		return &Result{Output: docs}, nil
+	}
+	return &Result{Output: out}, nil
+}
+
+// cueErrorDetails returns each of the errors within err with their positions.
+func cueErrorDetails(err error) string {
+	return cueerrors.Details(err, nil)
+}
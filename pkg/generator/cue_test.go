@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCUE(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "schema.cue"), []byte(`
+values: {
+	name:     string
+	replicas: int & <=10
+}
+out: {
+	name:     values.name
+	replicas: values.replicas * 2
+}
+`), 0o644)
+	require.NoError(t, err)
+
+	store := NewStore(nil)
+	generate := func(values string) (*Result, error) {
+		name := t.Name() + values
+		err := store.AddReference(name, &Result{Format: "yaml", Output: []byte(values)})
+		require.NoError(t, err)
+		return NewCUE(dir, config.CUEGenerator{
+			Paths:      []config.StringOrValue{{String: strPtr("schema.cue")}},
+			Input:      []config.CUEInput{{Path: config.StringOrValue{String: strPtr("values")}, Value: config.Value{Ref: name}}},
+			Expression: config.StringOrValue{String: strPtr("out")},
+		}, store).Generate(context.Background())
+	}
+
+	res, err := generate("name: app\nreplicas: 2\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "app", "replicas": int64(4)}, res.Output)
+
+	_, err = generate("name: app\nreplicas: 20\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CUE validation failed")
+	assert.Contains(t, err.Error(), "invalid value 20 (out of bound <=10)")
+
+	_, err = generate("name: app\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "out.replicas: non-concrete value")
+}
+
+func TestCUEValueInput(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "schema.cue"), []byte("replicas: int & <=10\nratio: number\n"), 0o644)
+	require.NoError(t, err)
+
+	// Numbers in the configuration are decoded as float64.
+	var values config.AnyOrValue
+	require.NoError(t, config.DecodeYAML([]byte("{replicas: 3, ratio: 0.5}"), &values))
+	res, err := NewCUE(dir, config.CUEGenerator{
+		Paths: []config.StringOrValue{{String: strPtr("schema.cue")}},
+		Input: []config.CUEInput{{Value: config.Value{Value: &values}}},
+	}, NewStore(nil)).Generate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"replicas": int64(3), "ratio": 0.5}, res.Output)
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chancez/yamlforge/pkg/config"
+)
+
+var _ Generator = (*Encode)(nil)
+
+// Encode returns its inputs encoded in a data format.
+type Encode struct {
+	dir      string
+	cfg      config.EncodeGenerator
+	refStore *Store
+}
+
+func NewEncode(dir string, cfg config.EncodeGenerator, refStore *Store) *Encode {
+	return &Encode{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+func (e *Encode) Generate(ctx context.Context) (*Result, error) {
+	format, err := e.refStore.GetStringValue(ctx, e.dir, e.cfg.Format)
+	if err != nil {
+		return nil, fmt.Errorf("error getting format: %w", err)
+	}
+	if format == "" {
+		return nil, errors.New("format is required")
+	}
+	c, err := GetCodec(format)
+	if err != nil {
+		return nil, err
+	}
+	var docs []any
+	for _, input := range e.cfg.Input {
+		vals, err := e.refStore.GetParsedValues(ctx, e.dir, input)
+		if err != nil {
+			return nil, fmt.Errorf("error getting value: %w", err)
+		}
+		for val, err := range vals {
+			if err != nil {
+				return nil, fmt.Errorf("error while processing input: %w", err)
+			}
+			docs = append(docs, val.Parsed())
+		}
+	}
+	out, err := c.Encode(docs)
+	if err != nil {
+		return nil, fmt.Errorf("error writing %s: %w", format, err)
+	}
+	return &Result{Output: out, Format: format}, nil
+}
+
+// formatEncodeConfig returns the encode generator configuration for a
+// generator which encodes its inputs in a fixed format.
+func formatEncodeConfig(format string, cfg config.FormatGenerator) config.EncodeGenerator {
+	return config.EncodeGenerator{
+		Format: config.StringOrValue{String: &format},
+		Input:  cfg.Input,
+	}
+}
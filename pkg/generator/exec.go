@@ -27,21 +27,21 @@ func NewExec(dir string, cfg config.ExecGenerator, refStore *Store) *Exec {
 	}
 }
 
-func (e *Exec) Generate(context.Context) (*Result, error) {
+func (e *Exec) Generate(ctx context.Context) (*Result, error) {
 	var env []string
 	for _, envVar := range e.cfg.Env {
-		data, err := e.refStore.GetValueBytes(e.dir, envVar.Value)
+		data, err := e.refStore.GetValueBytes(ctx, e.dir, envVar.Value)
 		if err != nil {
 			return nil, fmt.Errorf("error getting value: %w", err)
 		}
 		env = append(env, fmt.Sprintf("%s=%s", envVar.Name, string(data)))
 	}
 
-	command, err := e.refStore.GetStringValue(e.dir, e.cfg.Command)
+	command, err := e.refStore.GetStringValue(ctx, e.dir, e.cfg.Command)
 	if err != nil {
 		return nil, err
 	}
-	args, err := e.refStore.GetStringValueList(e.dir, e.cfg.Args)
+	args, err := e.refStore.GetStringValueList(ctx, e.dir, e.cfg.Args)
 	if err != nil {
 		return nil, err
 	}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"path"
-	"path/filepath"
 
 	"github.com/chancez/yamlforge/pkg/config"
 )
@@ -14,34 +13,28 @@ var _ Generator = (*File)(nil)
 
 type File struct {
 	// Path lookups are relative to the dir specified.
-	dir string
-	cfg config.FileGenerator
+	dir      string
+	cfg      config.FileGenerator
+	refStore *Store
 }
 
-func NewFile(dir string, cfg config.FileGenerator) *File {
+func NewFile(dir string, cfg config.FileGenerator, refStore *Store) *File {
 	return &File{
-		dir: dir,
-		cfg: cfg,
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
 	}
 }
 
 func (f *File) Generate(context.Context) (*Result, error) {
-	data, err := os.ReadFile(path.Join(f.dir, f.cfg.Path))
+	filePath, err := f.refStore.interpolateString(f.cfg.Path)
 	if err != nil {
-		return nil, fmt.Errorf("error reading %q: %w", f.cfg.Path, err)
+		return nil, fmt.Errorf("error interpolating path: %w", err)
 	}
-	format := formatFromFileName(f.cfg.Path)
-	return &Result{Output: data, Format: format}, nil
-}
-
-func formatFromFileName(f string) string {
-	ext := filepath.Ext(f)
-	switch ext {
-	case ".yml", ".yaml":
-		return "yaml"
-	case ".json":
-		return "json"
-	default:
-		return ""
+	data, err := os.ReadFile(path.Join(f.dir, filePath))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %q: %w", filePath, err)
 	}
+	format := formatFromFileName(filePath)
+	return &Result{Output: data, Format: format}, nil
 }
@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chancez/yamlforge/pkg/config"
+)
+
+// matchedFile is a file read by a glob or dir value.
+type matchedFile struct {
+	// path is the path used to read the file.
+	path string
+	// name is the path of the file relative to the directory being read.
+	name string
+}
+
+// getFilesValue returns the files matched by the glob or dir of ref, either
+// as a stream of their parsed documents, or a map of their names to content
+// if asMap is set.
+func getFilesValue(dir string, ref config.Value) (*Result, error) {
+	var files []matchedFile
+	var err error
+	if ref.Glob != "" {
+		files, err = globFiles(dir, ref.Glob)
+	} else {
+		files, err = dirFiles(dir, ref.Dir)
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && ref.IgnoreMissing {
+			return &Result{Output: ref.Default}, nil
+		}
+		return nil, err
+	}
+
+	return filesResult(ref, files, os.ReadFile)
+}
+
+// filesResult returns files as a stream of their parsed documents, or a map of
+// their names to content if asMap is set, using readFile to read each file.
+func filesResult(ref config.Value, files []matchedFile, readFile func(string) ([]byte, error)) (*Result, error) {
+	if ref.AsMap {
+		out := make(map[string]any, len(files))
+		for _, f := range files {
+			data, err := readFile(f.path)
+			if err != nil {
+				return nil, fmt.Errorf("error reading file %q: %w", f.path, err)
+			}
+			out[f.name] = string(data)
+		}
+		return &Result{Output: out}, nil
+	}
+
+	docs := []any{}
+	for _, f := range files {
+		data, err := readFile(f.path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file %q: %w", f.path, err)
+		}
+		format := formatFromFileName(f.name)
+		if format == "" {
+			format = ref.Format
+		}
+		if format == "" {
+			return nil, fmt.Errorf("unknown format for file %q, format must be set", f.path)
+		}
+		vals, err := parseResult(&Result{Output: data, Format: format}, "")
+		if err != nil {
+			return nil, fmt.Errorf("error parsing file %q: %w", f.path, err)
+		}
+		for val, err := range vals {
+			if err != nil {
+				return nil, fmt.Errorf("error parsing file %q: %w", f.path, err)
+			}
+			docs = append(docs, val.Parsed())
+		}
+	}
+	return &Result{Output: docs}, nil
+}
+
+// globFiles returns the files matching pattern within dir. Files are named
+// relative to the directory of the pattern, which is the part of the pattern
+// before the first path element containing a wildcard.
+func globFiles(dir, pattern string) ([]matchedFile, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	base := filepath.Join(dir, globBase(pattern))
+	var files []matchedFile
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file %q: %w", match, err)
+		}
+		if info.IsDir() {
+			continue
+		}
+		name, err := filepath.Rel(base, match)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matchedFile{path: match, name: filepath.ToSlash(name)})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("glob %q matched no files: %w", pattern, os.ErrNotExist)
+	}
+	return files, nil
+}
+
+// globBase returns the leading path elements of pattern which contain no
+// wildcards.
+func globBase(pattern string) string {
+	var base []string
+	for _, elem := range strings.Split(filepath.ToSlash(pattern), "/") {
+		if strings.ContainsAny(elem, `*?[\`) {
+			break
+		}
+		base = append(base, elem)
+	}
+	if len(base) == 0 {
+		return "."
+	}
+	return filepath.FromSlash(strings.Join(base, "/"))
+}
+
+// dirFiles returns every file within dir/name, including sub-directories, in
+// lexical order.
+func dirFiles(dir, name string) ([]matchedFile, error) {
+	root := filepath.Join(dir, name)
+	var files []matchedFile
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, matchedFile{path: p, name: filepath.ToSlash(rel)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading dir %q: %w", name, err)
+	}
+	return files, nil
+}
@@ -0,0 +1,563 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/clbanning/mxj/v2"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	hclprinter "github.com/hashicorp/hcl/hcl/printer"
+	hcltoken "github.com/hashicorp/hcl/hcl/token"
+	hcljson "github.com/hashicorp/hcl/json/parser"
+	"github.com/joho/godotenv"
+	"github.com/magiconair/properties"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/ini.v1"
+)
+
+// Codec decodes and encodes documents in a data format. Codecs are
+// registered by name using RegisterCodec, and are used for Value.format, file
+// extension detection and the encode generator.
+type Codec interface {
+	// Extensions returns the file extensions, including the leading dot, of
+	// files in the format.
+	Extensions() []string
+	// NewDecoder returns a decoder for the documents in data.
+	NewDecoder(data []byte) (Decoder, error)
+	// Encode encodes docs in the format.
+	Encode(docs []any) ([]byte, error)
+}
+
+// NewCodec returns a Codec which uses the provided functions to decode and
+// encode documents.
+func NewCodec(extensions []string, newDecoder func(data []byte) (Decoder, error), encode func(docs []any) ([]byte, error)) Codec {
+	return &codec{
+		extensions: extensions,
+		newDecoder: newDecoder,
+		encode:     encode,
+	}
+}
+
+type codec struct {
+	extensions []string
+	newDecoder func(data []byte) (Decoder, error)
+	encode     func(docs []any) ([]byte, error)
+}
+
+func (c *codec) Extensions() []string {
+	return c.extensions
+}
+
+func (c *codec) NewDecoder(data []byte) (Decoder, error) {
+	return c.newDecoder(data)
+}
+
+func (c *codec) Encode(docs []any) ([]byte, error) {
+	return c.encode(docs)
+}
+
+var (
+	codecsMu sync.RWMutex
+	// codecs are the registered codecs by name.
+	codecs = map[string]Codec{
+		"yaml": &codec{
+			extensions: []string{".yaml", ".yml"},
+			newDecoder: func(data []byte) (Decoder, error) {
+				return config.NewYAMLDecoder(bytes.NewBuffer(data)), nil
+			},
+			encode: encodeYAMLStream,
+		},
+		"json": &codec{
+			extensions: []string{".json"},
+			newDecoder: func(data []byte) (Decoder, error) {
+				return json.NewDecoder(bytes.NewBuffer(data)), nil
+			},
+			encode: encodeJSONStream,
+		},
+		"toml": &codec{
+			extensions: []string{".toml"},
+			newDecoder: singleDocumentDecoder(func(data []byte) (any, error) {
+				var doc map[string]any
+				err := toml.Unmarshal(data, &doc)
+				return doc, err
+			}),
+			encode: singleDocumentEncoder("toml", func(doc any) ([]byte, error) {
+				return toml.Marshal(doc)
+			}),
+		},
+		"hcl": &codec{
+			extensions: []string{".hcl"},
+			newDecoder: singleDocumentDecoder(decodeHCL),
+			encode:     singleDocumentEncoder("hcl", encodeHCL),
+		},
+		"ini": &codec{
+			extensions: []string{".ini"},
+			newDecoder: singleDocumentDecoder(decodeINI),
+			encode:     singleDocumentEncoder("ini", encodeINI),
+		},
+		"dotenv": &codec{
+			extensions: []string{".env"},
+			newDecoder: singleDocumentDecoder(func(data []byte) (any, error) {
+				env, err := godotenv.UnmarshalBytes(data)
+				if err != nil {
+					return nil, err
+				}
+				return stringMapToAny(env), nil
+			}),
+			encode: singleDocumentEncoder("dotenv", encodeDotenv),
+		},
+		"properties": &codec{
+			extensions: []string{".properties"},
+			newDecoder: singleDocumentDecoder(func(data []byte) (any, error) {
+				props, err := properties.Load(data, properties.UTF8)
+				if err != nil {
+					return nil, err
+				}
+				return stringMapToAny(props.Map()), nil
+			}),
+			encode: singleDocumentEncoder("properties", encodeProperties),
+		},
+		"xml": &codec{
+			extensions: []string{".xml"},
+			newDecoder: singleDocumentDecoder(func(data []byte) (any, error) {
+				m, err := mxj.NewMapXml(data)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any(m), nil
+			}),
+			encode: singleDocumentEncoder("xml", encodeXML),
+		},
+		"csv": &codec{
+			extensions: []string{".csv"},
+			newDecoder: newCSVDecoder,
+			encode:     encodeCSV,
+		},
+	}
+)
+
+// RegisterCodec makes a codec available under name. It returns an error if a
+// codec is already registered with the same name.
+func RegisterCodec(name string, c Codec) error {
+	if name == "" {
+		return errors.New("codec name is required")
+	}
+	if c == nil {
+		return fmt.Errorf("codec %q is nil", name)
+	}
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if _, exists := codecs[name]; exists {
+		return fmt.Errorf("codec %q is already registered", name)
+	}
+	codecs[name] = c
+	return nil
+}
+
+// GetCodec returns the codec registered under name.
+func GetCodec(name string) (Codec, error) {
+	if name == "" {
+		return nil, errors.New("input.format is required")
+	}
+	codecsMu.RLock()
+	c, ok := codecs[name]
+	codecsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("invalid input format specified: %q, must be one of %s", name, strings.Join(CodecNames(), ", "))
+	}
+	return c, nil
+}
+
+// CodecNames returns the sorted names of the registered codecs.
+func CodecNames() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatFromFileName returns the name of the format of a file based on its
+// extension, or an empty string if it's unknown.
+func formatFromFileName(f string) string {
+	ext := filepath.Ext(f)
+	for _, name := range CodecNames() {
+		c, err := GetCodec(name)
+		if err != nil {
+			continue
+		}
+		for _, codecExt := range c.Extensions() {
+			if ext == codecExt {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// encodeDocuments encodes docs in the named format.
+func encodeDocuments(name string, docs []any) ([]byte, error) {
+	c, err := GetCodec(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Encode(docs)
+}
+
+func encodeYAMLStream(docs []any) ([]byte, error) {
+	var out bytes.Buffer
+	enc := config.NewYAMLEncoder(&out)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func encodeJSONStream(docs []any) ([]byte, error) {
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// sliceDecoder is a Decoder returning each of a list of decoded documents.
+type sliceDecoder struct {
+	docs []any
+}
+
+func (d *sliceDecoder) Decode(v any) error {
+	if len(d.docs) == 0 {
+		return io.EOF
+	}
+	ptr, ok := v.(*any)
+	if !ok {
+		return fmt.Errorf("cannot decode into %T", v)
+	}
+	*ptr = d.docs[0]
+	d.docs = d.docs[1:]
+	return nil
+}
+
+// singleDocumentDecoder returns a decoder constructor for formats which
+// contain a single document.
+func singleDocumentDecoder(decode func(data []byte) (any, error)) func(data []byte) (Decoder, error) {
+	return func(data []byte) (Decoder, error) {
+		doc, err := decode(data)
+		if err != nil {
+			return nil, err
+		}
+		return &sliceDecoder{docs: []any{doc}}, nil
+	}
+}
+
+// singleDocumentEncoder returns an encoder for formats which contain a single
+// document.
+func singleDocumentEncoder(name string, encode func(doc any) ([]byte, error)) func(docs []any) ([]byte, error) {
+	return func(docs []any) ([]byte, error) {
+		if len(docs) != 1 {
+			return nil, fmt.Errorf("%s does not support multiple documents, got %d", name, len(docs))
+		}
+		return encode(docs[0])
+	}
+}
+
+func stringMapToAny(m map[string]string) map[string]any {
+	ret := make(map[string]any, len(m))
+	for k, v := range m {
+		ret[k] = v
+	}
+	return ret
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatScalar converts a scalar value into a string for formats which only
+// support string values.
+func formatScalar(v any) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32), nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(val), nil
+	default:
+		return "", fmt.Errorf("cannot convert %T to a string", v)
+	}
+}
+
+func decodeHCL(data []byte) (any, error) {
+	var doc map[string]any
+	if err := hcl.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	// Normalize the types used for blocks, such as []map[string]any, into
+	// the types used by other formats.
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var ret any
+	err = json.Unmarshal(encoded, &ret)
+	return ret, err
+}
+
+var hclIdentifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+func encodeHCL(doc any) ([]byte, error) {
+	// HCL can parse JSON into its AST, which can then be printed as HCL.
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	file, err := hcljson.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	// Keys parsed from JSON are strings, unquote them where possible.
+	ast.Walk(file.Node, func(n ast.Node) (ast.Node, bool) {
+		if key, ok := n.(*ast.ObjectKey); ok && key.Token.Type == hcltoken.STRING {
+			if name, err := strconv.Unquote(key.Token.Text); err == nil && hclIdentifier.MatchString(name) {
+				key.Token.Type = hcltoken.IDENT
+				key.Token.Text = name
+			}
+		}
+		return n, true
+	})
+	var out bytes.Buffer
+	if err := hclprinter.Fprint(&out, file); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// decodeINI decodes an INI file into a map of sections, with keys outside of
+// a section at the top level.
+func decodeINI(data []byte) (any, error) {
+	file, err := ini.Load(data)
+	if err != nil {
+		return nil, err
+	}
+	doc := make(map[string]any)
+	for _, section := range file.Sections() {
+		keys := make(map[string]any)
+		for _, key := range section.Keys() {
+			keys[key.Name()] = key.Value()
+		}
+		if section.Name() == ini.DefaultSection {
+			for k, v := range keys {
+				doc[k] = v
+			}
+			continue
+		}
+		doc[section.Name()] = keys
+	}
+	return doc, nil
+}
+
+func encodeINI(doc any) ([]byte, error) {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("ini documents must be objects, got %T", doc)
+	}
+	file := ini.Empty()
+	for _, name := range sortedKeys(m) {
+		if sectionKeys, ok := m[name].(map[string]any); ok {
+			section, err := file.NewSection(name)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range sortedKeys(sectionKeys) {
+				val, err := formatScalar(sectionKeys[key])
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %w", name, key, err)
+				}
+				if _, err := section.NewKey(key, val); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		val, err := formatScalar(m[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if _, err := file.Section("").NewKey(name, val); err != nil {
+			return nil, err
+		}
+	}
+	var out bytes.Buffer
+	if _, err := file.WriteTo(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func encodeDotenv(doc any) ([]byte, error) {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("dotenv documents must be objects, got %T", doc)
+	}
+	env := make(map[string]string, len(m))
+	for k, v := range m {
+		val, err := formatScalar(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		env[k] = val
+	}
+	out, err := godotenv.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out + "\n"), nil
+}
+
+// encodeProperties encodes doc as Java properties, joining the keys of nested
+// objects with dots.
+func encodeProperties(doc any) ([]byte, error) {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("properties documents must be objects, got %T", doc)
+	}
+	flat := make(map[string]any)
+	var flatten func(prefix string, m map[string]any)
+	flatten = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			if nested, ok := v.(map[string]any); ok {
+				flatten(prefix+k+".", nested)
+				continue
+			}
+			flat[prefix+k] = v
+		}
+	}
+	flatten("", m)
+
+	props := properties.NewProperties()
+	for _, key := range sortedKeys(flat) {
+		val, err := formatScalar(flat[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if _, _, err := props.Set(key, val); err != nil {
+			return nil, err
+		}
+	}
+	var out bytes.Buffer
+	if _, err := props.Write(&out, properties.UTF8); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func encodeXML(doc any) ([]byte, error) {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("xml documents must be objects, got %T", doc)
+	}
+	data, err := mxj.Map(m).XmlIndent("", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// newCSVDecoder returns a decoder returning each row of a CSV file with a
+// header as a separate document.
+func newCSVDecoder(data []byte) (Decoder, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return &sliceDecoder{}, nil
+	}
+	header := records[0]
+	docs := make([]any, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]any, len(header))
+		for i, col := range header {
+			row[col] = record[i]
+		}
+		docs = append(docs, row)
+	}
+	return &sliceDecoder{docs: docs}, nil
+}
+
+// encodeCSV encodes each document as a row, with a header containing the keys
+// of every document.
+func encodeCSV(docs []any) ([]byte, error) {
+	columns := make(map[string]any)
+	rows := make([]map[string]any, 0, len(docs))
+	for i, doc := range docs {
+		row, ok := doc.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("document[%d]: csv documents must be objects, got %T", i, doc)
+		}
+		for k := range row {
+			columns[k] = nil
+		}
+		rows = append(rows, row)
+	}
+	header := sortedKeys(columns)
+
+	var out bytes.Buffer
+	w := csv.NewWriter(&out)
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for i, row := range rows {
+		record := make([]string, len(header))
+		for j, col := range header {
+			val, err := formatScalar(row[col])
+			if err != nil {
+				return nil, fmt.Errorf("document[%d].%s: %w", i, col, err)
+			}
+			record[j] = val
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,159 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeAll(t *testing.T, format string, data string) []any {
+	t.Helper()
+	dec, err := NewDecoder(format, []byte(data))
+	require.NoError(t, err)
+	var docs []any
+	for {
+		var doc any
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			return docs
+		}
+		require.NoError(t, err)
+		docs = append(docs, doc)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		format  string
+		encoded string
+		decoded []any
+	}{
+		{
+			format:  "toml",
+			encoded: "name = 'app'\n\n[server]\nport = '8080'\n",
+			decoded: []any{map[string]any{"name": "app", "server": map[string]any{"port": "8080"}}},
+		},
+		{
+			format:  "hcl",
+			encoded: "name = \"app\"\n\nserver = {\n  port = \"8080\"\n}\n",
+			decoded: []any{map[string]any{"name": "app", "server": []any{map[string]any{"port": "8080"}}}},
+		},
+		{
+			format:  "ini",
+			encoded: "name = app\n\n[server]\nport = 8080\n",
+			decoded: []any{map[string]any{"name": "app", "server": map[string]any{"port": "8080"}}},
+		},
+		{
+			format:  "dotenv",
+			encoded: "NAME=\"app\"\nPORT=8080\n",
+			decoded: []any{map[string]any{"NAME": "app", "PORT": "8080"}},
+		},
+		{
+			format:  "properties",
+			encoded: "name = app\nserver.port = 8080\n",
+			decoded: []any{map[string]any{"name": "app", "server.port": "8080"}},
+		},
+		{
+			format:  "xml",
+			encoded: "<server>\n  <name>app</name>\n  <port>8080</port>\n</server>\n",
+			decoded: []any{map[string]any{"server": map[string]any{"name": "app", "port": "8080"}}},
+		},
+		{
+			format:  "csv",
+			encoded: "name,port\napp,8080\ndb,5432\n",
+			decoded: []any{map[string]any{"name": "app", "port": "8080"}, map[string]any{"name": "db", "port": "5432"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			assert.Equal(t, tt.decoded, decodeAll(t, tt.format, tt.encoded))
+			out, err := encodeDocuments(tt.format, tt.decoded)
+			require.NoError(t, err)
+			assert.Equal(t, tt.encoded, string(out))
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	_, err := encodeDocuments("toml", []any{map[string]any{}, map[string]any{}})
+	assert.EqualError(t, err, "toml does not support multiple documents, got 2")
+
+	_, err = encodeDocuments("dotenv", []any{map[string]any{"A": []any{1}}})
+	assert.EqualError(t, err, "A: cannot convert []interface {} to a string")
+
+	_, err = NewDecoder("cue", nil)
+	assert.ErrorContains(t, err, `invalid input format specified: "cue"`)
+}
+
+func TestFormatFromFileName(t *testing.T) {
+	assert.Equal(t, "yaml", formatFromFileName("values.yml"))
+	assert.Equal(t, "dotenv", formatFromFileName(".env"))
+	assert.Equal(t, "properties", formatFromFileName("config/app.properties"))
+	assert.Equal(t, "", formatFromFileName("README.md"))
+}
+
+func TestRegisterCodec(t *testing.T) {
+	// lines is a format with one string document per line.
+	lines := NewCodec([]string{".lines"},
+		func(data []byte) (Decoder, error) {
+			var docs []any
+			for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+				docs = append(docs, line)
+			}
+			return &sliceDecoder{docs: docs}, nil
+		},
+		func(docs []any) ([]byte, error) {
+			var out strings.Builder
+			for _, doc := range docs {
+				fmt.Fprintln(&out, doc)
+			}
+			return []byte(out.String()), nil
+		},
+	)
+	require.NoError(t, RegisterCodec("lines", lines))
+	t.Cleanup(func() {
+		codecsMu.Lock()
+		delete(codecs, "lines")
+		codecsMu.Unlock()
+	})
+
+	assert.EqualError(t, RegisterCodec("lines", lines), `codec "lines" is already registered`)
+	assert.EqualError(t, RegisterCodec("yaml", lines), `codec "yaml" is already registered`)
+	assert.Equal(t, "lines", formatFromFileName("hosts.lines"))
+	assert.Contains(t, CodecNames(), "lines")
+
+	store := NewStore(nil)
+	err := store.AddReference("hosts", &Result{Output: []byte("a.example.com\nb.example.com\n")})
+	require.NoError(t, err)
+	vals, err := store.GetParsedValues(context.Background(), "", config.Value{Ref: "hosts", Format: "lines"})
+	require.NoError(t, err)
+	var docs []any
+	for val, err := range vals {
+		require.NoError(t, err)
+		docs = append(docs, val.Parsed())
+	}
+	assert.Equal(t, []any{"a.example.com", "b.example.com"}, docs)
+
+	format := "lines"
+	gen := NewEncode("", config.EncodeGenerator{
+		Format: config.StringOrValue{String: &format},
+		Input:  []config.Value{{Ref: "hosts", Format: "lines"}},
+	}, store)
+	res, err := gen.Generate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "a.example.com\nb.example.com\n", string(res.Output.([]byte)))
+	assert.Equal(t, "lines", res.Format)
+}
+
+func TestConvertToBytesUsesCodec(t *testing.T) {
+	out, err := ConvertToBytes(&Result{Output: map[string]any{"a": 1}, Format: "json"})
+	require.NoError(t, err)
+	assert.Equal(t, "{\"a\":1}\n", string(out))
+}
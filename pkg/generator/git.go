@@ -0,0 +1,211 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/chancez/yamlforge/pkg/config"
+)
+
+// commitPattern matches full commit hashes, which always refer to the same
+// content, so a cached repository containing the commit doesn't need to be
+// fetched again.
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// getGitValue returns the file at the path of the git source of ref, or the
+// files matching its glob, like a file or glob value.
+func (store *Store) getGitValue(ctx context.Context, dir string, ref config.Value) (*Result, error) {
+	src := *ref.Git
+	repo, err := store.GetStringValue(ctx, dir, src.Repo)
+	if err != nil {
+		return nil, fmt.Errorf("error getting repo: %w", err)
+	}
+	if repo == "" {
+		return nil, errors.New("git: repo is required")
+	}
+	rev, err := store.GetStringValue(ctx, dir, src.Ref)
+	if err != nil {
+		return nil, fmt.Errorf("error getting ref: %w", err)
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+	filePath, err := store.GetStringValue(ctx, dir, src.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error getting path: %w", err)
+	}
+	pattern, err := store.GetStringValue(ctx, dir, src.Glob)
+	if err != nil {
+		return nil, fmt.Errorf("error getting glob: %w", err)
+	}
+	if (filePath == "") == (pattern == "") {
+		return nil, errors.New("git: exactly one of path or glob must be set")
+	}
+
+	gitDir, err := store.gitRepository(ctx, dir, repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := runGit(ctx, gitDir, "rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("error resolving ref %q in %s: %w", rev, repo, err)
+	}
+	commit = strings.TrimSpace(commit)
+	readFile := func(name string) ([]byte, error) {
+		out, err := runGit(ctx, gitDir, "cat-file", "blob", commit+":"+name)
+		return []byte(out), err
+	}
+
+	if filePath != "" {
+		filePath = path.Clean(strings.TrimPrefix(filePath, "/"))
+		if _, err := runGit(ctx, gitDir, "cat-file", "-e", commit+":"+filePath); err != nil {
+			if ref.IgnoreMissing {
+				return &Result{Output: ref.Default}, nil
+			}
+			return nil, fmt.Errorf("file %q does not exist at %s in %s", filePath, rev, repo)
+		}
+		data, err := readFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file %q: %w", filePath, err)
+		}
+		return &Result{Output: data, Format: formatFromFileName(filePath)}, nil
+	}
+
+	out, err := runGit(ctx, gitDir, "ls-tree", "-r", "--name-only", "-z", commit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing files at %s in %s: %w", rev, repo, err)
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+	base := filepath.ToSlash(globBase(pattern))
+	var files []matchedFile
+	for _, name := range strings.Split(out, "\x00") {
+		if name == "" {
+			continue
+		}
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+		if !ok {
+			continue
+		}
+		rel := strings.TrimPrefix(name, base+"/")
+		if base == "." {
+			rel = name
+		}
+		files = append(files, matchedFile{path: name, name: rel})
+	}
+	if len(files) == 0 {
+		if ref.IgnoreMissing {
+			return &Result{Output: ref.Default}, nil
+		}
+		return nil, fmt.Errorf("glob %q matched no files at %s in %s", pattern, rev, repo)
+	}
+	return filesResult(ref, files, readFile)
+}
+
+// gitRepository returns the git directory to read repo from. Local
+// repositories are used directly, and remote repositories are cloned into the
+// cache directory, and fetched unless rev is a commit which is already cached.
+func (store *Store) gitRepository(ctx context.Context, dir, repo, rev string) (string, error) {
+	if !isRemoteRepository(repo) {
+		if !filepath.IsAbs(repo) {
+			repo = filepath.Join(dir, repo)
+		}
+		return repo, nil
+	}
+
+	cacheDir, err := store.getCacheDir("git")
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(repo))
+	gitDir := filepath.Join(cacheDir, hex.EncodeToString(sum[:]))
+	_, statErr := os.Stat(gitDir)
+	cached := statErr == nil
+
+	switch {
+	case store.offline:
+		if !cached {
+			return "", fmt.Errorf("repository %s is not cached, cannot clone while offline", repo)
+		}
+		return gitDir, nil
+	case !cached:
+		if err := os.MkdirAll(cacheDir, 0o750); err != nil {
+			return "", fmt.Errorf("error creating cache directory: %w", err)
+		}
+		// Clone into a temporary directory and rename it so that an interrupted
+		// clone isn't used as the cache.
+		tmpDir, err := os.MkdirTemp(cacheDir, filepath.Base(gitDir)+".*")
+		if err != nil {
+			return "", fmt.Errorf("error creating cache directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		if _, err := runGit(ctx, "", "clone", "--bare", "--quiet", "--", repo, tmpDir); err != nil {
+			return "", fmt.Errorf("error cloning %s: %w", repo, err)
+		}
+		if err := os.Rename(tmpDir, gitDir); err != nil {
+			return "", fmt.Errorf("error caching %s: %w", repo, err)
+		}
+		return gitDir, nil
+	}
+
+	if commitPattern.MatchString(rev) {
+		if _, err := runGit(ctx, gitDir, "cat-file", "-e", rev+"^{commit}"); err == nil {
+			return gitDir, nil
+		}
+	}
+	_, err = runGit(ctx, gitDir, "fetch", "--quiet", "--prune", "--tags", "origin", "+refs/heads/*:refs/heads/*")
+	if err != nil {
+		return "", fmt.Errorf("error fetching %s: %w", repo, err)
+	}
+	if commitPattern.MatchString(rev) {
+		if _, err := runGit(ctx, gitDir, "cat-file", "-e", rev+"^{commit}"); err != nil {
+			// Commits which aren't on a branch or tag must be fetched directly.
+			if _, err := runGit(ctx, gitDir, "fetch", "--quiet", "origin", rev); err != nil {
+				return "", fmt.Errorf("error fetching %s from %s: %w", rev, repo, err)
+			}
+		}
+	}
+	return gitDir, nil
+}
+
+// isRemoteRepository reports if repo is a URL or scp-like address such as
+// git@github.com:org/repo.git rather than a local path.
+func isRemoteRepository(repo string) bool {
+	if strings.Contains(repo, "://") {
+		return true
+	}
+	host, _, ok := strings.Cut(repo, ":")
+	return ok && strings.Contains(host, "@") && !strings.Contains(host, "/")
+}
+
+// runGit runs git with args in gitDir and returns its output.
+func runGit(ctx context.Context, gitDir string, args ...string) (string, error) {
+	if gitDir != "" {
+		args = append([]string{"-C", gitDir}, args...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't prompt for credentials, which would block the pipeline.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGitRepo creates a git repository with a commit for each set of files,
+// and returns its path and the hashes of each commit.
+func newGitRepo(t *testing.T, commits ...map[string]string) (string, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := runGit(context.Background(), dir, args...)
+		require.NoError(t, err)
+		return strings.TrimSpace(out)
+	}
+	git("init", "--quiet", "--initial-branch=main")
+	git("config", "user.name", "test")
+	git("config", "user.email", "test@example.com")
+	git("config", "commit.gpgsign", "false")
+	var hashes []string
+	for i, files := range commits {
+		for name, content := range files {
+			p := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(p), 0750))
+			require.NoError(t, os.WriteFile(p, []byte(content), 0640))
+		}
+		git("add", "--all")
+		git("commit", "--quiet", "--message", fmt.Sprintf("commit %d", i+1))
+		hashes = append(hashes, git("rev-parse", "HEAD"))
+	}
+	return dir, hashes
+}
+
+func TestGit(t *testing.T) {
+	repo, commits := newGitRepo(t,
+		map[string]string{
+			"values.yaml":          "image: v1\n",
+			"manifests/a.yaml":     "name: a\n",
+			"manifests/b.json":     `{"name": "b"}`,
+			"manifests/c/d.yaml":   "name: d\n",
+			"manifests/readme.txt": "not a manifest\n",
+		},
+		map[string]string{
+			"values.yaml": "image: v2\n",
+		},
+	)
+	store := NewStore(nil)
+	cacheDir := t.TempDir()
+	store.SetCache(cacheDir, false)
+	str := func(s string) config.StringOrValue {
+		return config.StringOrValue{String: &s}
+	}
+	get := func(val config.Value) (*Result, error) {
+		t.Helper()
+		return store.GetValue(context.Background(), filepath.Dir(repo), val)
+	}
+
+	for _, repoURL := range []string{filepath.Base(repo), "file://" + repo} {
+		t.Run(repoURL, func(t *testing.T) {
+			res, err := get(config.Value{Git: &config.GitSource{Repo: str(repoURL), Path: str("values.yaml")}})
+			require.NoError(t, err)
+			assert.Equal(t, "image: v2\n", string(res.Output.([]byte)))
+			assert.Equal(t, "yaml", res.Format)
+
+			res, err = get(config.Value{Git: &config.GitSource{Repo: str(repoURL), Ref: str("HEAD~1"), Path: str("values.yaml")}, Path: "/image"})
+			require.NoError(t, err)
+			assert.Equal(t, "v1", res.Output)
+
+			res, err = get(config.Value{Git: &config.GitSource{Repo: str(repoURL), Ref: str(commits[0]), Path: str("values.yaml")}})
+			require.NoError(t, err)
+			assert.Equal(t, "image: v1\n", string(res.Output.([]byte)))
+
+			res, err = get(config.Value{Git: &config.GitSource{Repo: str(repoURL), Glob: str("manifests/*.*")}, Format: "yaml"})
+			require.NoError(t, err)
+			assert.Equal(t, []any{
+				map[string]any{"name": "a"},
+				map[string]any{"name": "b"},
+				"not a manifest",
+			}, res.Output)
+
+			res, err = get(config.Value{Git: &config.GitSource{Repo: str(repoURL), Glob: str("manifests/*/*.yaml")}, AsMap: true})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]any{"c/d.yaml": "name: d\n"}, res.Output)
+
+			_, err = get(config.Value{Git: &config.GitSource{Repo: str(repoURL), Path: str("missing.yaml")}})
+			assert.ErrorContains(t, err, `file "missing.yaml" does not exist at HEAD`)
+
+			res, err = get(config.Value{Git: &config.GitSource{Repo: str(repoURL), Path: str("missing.yaml")}, IgnoreMissing: true, Default: "default"})
+			require.NoError(t, err)
+			assert.Equal(t, "default", res.Output)
+
+			_, err = get(config.Value{Git: &config.GitSource{Repo: str(repoURL), Ref: str("missing"), Path: str("values.yaml")}})
+			assert.ErrorContains(t, err, `error resolving ref "missing"`)
+		})
+	}
+
+	// Remote repositories are read from the cache when offline.
+	require.NoError(t, os.RemoveAll(repo))
+	store.SetCache(cacheDir, true)
+	res, err := get(config.Value{Git: &config.GitSource{Repo: str("file://" + repo), Ref: str("main"), Path: str("values.yaml")}})
+	require.NoError(t, err)
+	assert.Equal(t, "image: v2\n", string(res.Output.([]byte)))
+	_, err = get(config.Value{Git: &config.GitSource{Repo: str("file:///does-not-exist"), Path: str("values.yaml")}})
+	assert.EqualError(t, err, "repository file:///does-not-exist is not cached, cannot clone while offline")
+}
+
+func TestIsRemoteRepository(t *testing.T) {
+	assert.True(t, isRemoteRepository("https://github.com/chancez/yamlforge.git"))
+	assert.True(t, isRemoteRepository("git@github.com:chancez/yamlforge.git"))
+	assert.False(t, isRemoteRepository("../yamlforge"))
+	assert.False(t, isRemoteRepository("/src/yamlforge"))
+}
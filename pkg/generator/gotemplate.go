@@ -3,8 +3,12 @@ package generator
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"text/template"
 
 	"github.com/Masterminds/sprig/v3"
@@ -22,6 +26,92 @@ var extraTemplateFuncs = template.FuncMap{
 		}
 		return val, nil
 	},
+	// toYaml encodes val as YAML using the same settings as the yaml
+	// generator, without the trailing newline. Top-level sequences aren't
+	// indented, so the result can be indented using nindent.
+	"toYaml": func(val any) (string, error) {
+		data, err := config.EncodeYAML(val)
+		if err != nil {
+			return "", err
+		}
+		return dedent(strings.TrimSuffix(string(data), "\n")), nil
+	},
+	// toJson and toRawJson replace those of sprig to match the YAML encoder,
+	// which doesn't escape HTML characters, and return encoding errors rather
+	// than an empty string. sprig's toPrettyJson is kept, so templates written
+	// for Helm render the same.
+	"toJson": func(val any) (string, error) {
+		return encodeTemplateJSON(val)
+	},
+	"toRawJson": func(val any) (string, error) {
+		return encodeTemplateJSON(val)
+	},
+	"fromYaml": func(data string) (map[string]any, error) {
+		var ret map[string]any
+		if err := config.DecodeYAML([]byte(data), &ret); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	},
+	"fromYamlArray": func(data string) ([]any, error) {
+		var ret []any
+		if err := config.DecodeYAML([]byte(data), &ret); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	},
+	"fromJson": func(data string) (map[string]any, error) {
+		var ret map[string]any
+		if err := json.Unmarshal([]byte(data), &ret); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	},
+	"fromJsonArray": func(data string) ([]any, error) {
+		var ret []any
+		if err := json.Unmarshal([]byte(data), &ret); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	},
+}
+
+// dedent removes the indent common to every non-blank line of s.
+func dedent(s string) string {
+	lines := strings.Split(s, "\n")
+	indent := -1
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent == -1 || n < indent {
+			indent = n
+		}
+	}
+	if indent <= 0 {
+		return s
+	}
+	for i, line := range lines {
+		if len(line) < indent {
+			lines[i] = strings.TrimLeft(line, " ")
+		} else {
+			lines[i] = line[indent:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// encodeTemplateJSON encodes val as JSON without escaping HTML characters,
+// without the trailing newline.
+func encodeTemplateJSON(val any) (string, error) {
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(val); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
 }
 
 var _ Generator = (*GoTemplate)(nil)
@@ -40,10 +130,33 @@ func NewGoTemplate(dir string, cfg config.GoTemplateGenerator, refStore *Store)
 	}
 }
 
-func (gt *GoTemplate) Generate(_ context.Context) (*Result, error) {
-	var buf bytes.Buffer
-	tpl := template.New("go-template-generator").Option("missingkey=error").Funcs(sprig.FuncMap()).Funcs(extraTemplateFuncs)
-	val, err := gt.refStore.GetStringValue(gt.dir, gt.cfg.Template)
+func (gt *GoTemplate) Generate(ctx context.Context) (*Result, error) {
+	missingKey, err := gt.refStore.GetStringValue(ctx, gt.dir, gt.cfg.MissingKey)
+	if err != nil {
+		return nil, fmt.Errorf("error getting missingKey: %w", err)
+	}
+	switch missingKey {
+	case "":
+		missingKey = "error"
+	case "error", "zero", "default":
+	default:
+		return nil, fmt.Errorf("invalid missingKey %q, must be one of error, zero or default", missingKey)
+	}
+
+	tpl := template.New("go-template-generator").Option("missingkey=" + missingKey).Funcs(sprig.FuncMap()).Funcs(extraTemplateFuncs)
+	tpl = tpl.Funcs(gt.templateFuncs(tpl))
+	if gt.cfg.Delims != nil {
+		left, err := gt.refStore.GetRawStringValue(ctx, gt.dir, gt.cfg.Delims.Left)
+		if err != nil {
+			return nil, fmt.Errorf("error getting delims.left: %w", err)
+		}
+		right, err := gt.refStore.GetRawStringValue(ctx, gt.dir, gt.cfg.Delims.Right)
+		if err != nil {
+			return nil, fmt.Errorf("error getting delims.right: %w", err)
+		}
+		tpl = tpl.Delims(left, right)
+	}
+	val, err := gt.refStore.GetRawStringValue(ctx, gt.dir, gt.cfg.Template)
 	if err != nil {
 		return nil, fmt.Errorf("error getting value for 'template': %w", err)
 	}
@@ -52,12 +165,43 @@ func (gt *GoTemplate) Generate(_ context.Context) (*Result, error) {
 		return nil, fmt.Errorf("error parsing template: %w", err)
 	}
 
+	patterns, err := gt.refStore.GetStringValueList(ctx, gt.dir, gt.cfg.Partials)
+	if err != nil {
+		return nil, fmt.Errorf("error getting partials: %w", err)
+	}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(gt.dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("partials: invalid pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("partials: pattern %q matched no files", pattern)
+		}
+		for _, match := range matches {
+			data, err := os.ReadFile(match)
+			if err != nil {
+				return nil, fmt.Errorf("partials: error reading %q: %w", match, err)
+			}
+			_, err = tpl.New(filepath.Base(match)).Parse(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("partials: error parsing %q: %w", match, err)
+			}
+		}
+	}
+
+	if gt.cfg.Input != nil {
+		if len(gt.cfg.Vars) != 0 {
+			return nil, errors.New("vars cannot be used with input")
+		}
+		return gt.generatePerDocument(ctx, tpl, missingKey)
+	}
+
 	vars := make(map[string]any)
 	for name, ref := range gt.cfg.Vars {
 		if name == "" {
 			return nil, fmt.Errorf("vars: variable name cannot be empty")
 		}
-		v, err := gt.refStore.GetAnyValue(gt.dir, ref)
+		v, err := gt.refStore.GetAnyValue(ctx, gt.dir, ref)
 		if err != nil {
 			return nil, fmt.Errorf("variable %q: error getting value: %w", name, err)
 		}
@@ -69,9 +213,103 @@ func (gt *GoTemplate) Generate(_ context.Context) (*Result, error) {
 		vars[name] = varVal
 	}
 
+	var buf bytes.Buffer
 	err = tpl.Execute(&buf, vars)
 	if err != nil {
 		return nil, fmt.Errorf("error executing template: %w", err)
 	}
-	return &Result{Output: buf.Bytes()}, nil
+	return &Result{Output: zeroMissingValues(buf.Bytes(), missingKey)}, nil
+}
+
+// zeroMissingValues removes the "<no value>" printed for missing keys of
+// interface maps, such as decoded YAML, when missingKey is 'zero', matching
+// Helm. text/template only prints the zero value of typed maps.
+func zeroMissingValues(out []byte, missingKey string) []byte {
+	if missingKey != "zero" {
+		return out
+	}
+	return bytes.ReplaceAll(out, []byte("<no value>"), nil)
+}
+
+// generatePerDocument executes tpl once for each document of the input,
+// returning the results as a YAML stream.
+func (gt *GoTemplate) generatePerDocument(ctx context.Context, tpl *template.Template, missingKey string) (*Result, error) {
+	vals, err := gt.refStore.GetParsedValues(ctx, gt.dir, *gt.cfg.Input)
+	if err != nil {
+		return nil, fmt.Errorf("error getting input: %w", err)
+	}
+	var buf bytes.Buffer
+	i := 0
+	for val, err := range vals {
+		if err != nil {
+			return nil, fmt.Errorf("error while processing input: %w", err)
+		}
+		if val.Parsed() == nil {
+			continue
+		}
+		if i != 0 {
+			if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+				buf.WriteByte('\n')
+			}
+			buf.WriteString("---\n")
+		}
+		if err := tpl.Execute(&buf, val.Parsed()); err != nil {
+			return nil, fmt.Errorf("document[%d]: error executing template: %w", i, err)
+		}
+		i++
+	}
+	return &Result{Output: zeroMissingValues(buf.Bytes(), missingKey), Format: "yaml"}, nil
+}
+
+// templateFuncs returns the template functions which depend on the pipeline
+// or on tpl itself.
+func (gt *GoTemplate) templateFuncs(tpl *template.Template) template.FuncMap {
+	return template.FuncMap{
+		// ref returns the output of a previous stage, parsed using its format.
+		"ref": func(name string) (any, error) {
+			res, ok := gt.refStore.references[name]
+			if !ok {
+				return nil, fmt.Errorf("could not find reference %q", name)
+			}
+			val, err := parseReference(res)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing reference %q: %w", name, err)
+			}
+			return val, nil
+		},
+		// var returns a pipeline variable.
+		"var": func(name string) (any, error) {
+			val, ok := gt.refStore.vars[name]
+			if !ok {
+				return nil, fmt.Errorf("could not find variable %q", name)
+			}
+			return val, nil
+		},
+		// include executes the named template and returns the result, so it
+		// can be piped to other functions.
+		"include": func(name string, data any) (string, error) {
+			var buf bytes.Buffer
+			if err := tpl.ExecuteTemplate(&buf, name, data); err != nil {
+				return "", err
+			}
+			return buf.String(), nil
+		},
+		// tpl executes text as a template, with access to the same named
+		// templates as the current template.
+		"tpl": func(text string, data any) (string, error) {
+			t, err := tpl.Clone()
+			if err != nil {
+				return "", fmt.Errorf("error cloning template: %w", err)
+			}
+			t, err = t.New("tpl").Parse(text)
+			if err != nil {
+				return "", fmt.Errorf("error parsing template: %w", err)
+			}
+			var buf bytes.Buffer
+			if err := t.Execute(&buf, data); err != nil {
+				return "", err
+			}
+			return buf.String(), nil
+		},
+	}
 }
@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoTemplateFuncs(t *testing.T) {
+	store := NewStore(map[string]any{"env": "prod"})
+	err := store.AddReference("values", &Result{
+		Format: "yaml",
+		Output: []byte("name: app\nports: [80, 443]\n"),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{name: "ref", template: `{{ (ref "values").name }}`, expected: "app"},
+		{name: "var", template: `{{ var "env" }}`, expected: "prod"},
+		{name: "toYaml", template: `{{ toYaml (ref "values").ports }}`, expected: "- 80\n- 443"},
+		{name: "toYaml nindent", template: `ports:{{ toYaml (ref "values").ports | nindent 2 }}`, expected: "ports:\n  - 80\n  - 443"},
+		{name: "toYaml map", template: `{{ toYaml (ref "values") }}`, expected: "name: app\nports:\n    - 80\n    - 443"},
+		{name: "toJson", template: `{{ toJson (dict "a" "<b>") }}`, expected: `{"a":"<b>"}`},
+		{name: "toRawJson", template: `{{ toRawJson (dict "a" "<b>") }}`, expected: `{"a":"<b>"}`},
+		{name: "toPrettyJson", template: `{{ toPrettyJson (ref "values") }}`, expected: "{\n  \"name\": \"app\",\n  \"ports\": [\n    80,\n    443\n  ]\n}"},
+		{name: "fromYaml", template: `{{ (fromYaml "a: b").a }}`, expected: "b"},
+		{name: "fromYamlArray", template: `{{ index (fromYamlArray "[1, 2]") 1 }}`, expected: "2"},
+		{name: "fromJson", template: `{{ (fromJson "{\"a\": \"b\"}").a }}`, expected: "b"},
+		{name: "include", template: `{{ define "greeting" }}hello {{ . }}{{ end }}{{ include "greeting" "world" | upper }}`, expected: "HELLO WORLD"},
+		{name: "tpl", template: `{{ define "name" }}app{{ end }}{{ tpl "{{ include \"name\" . }}-{{ . }}" "prod" }}`, expected: "app-prod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewGoTemplate("", config.GoTemplateGenerator{
+				Template: config.StringOrValue{String: strPtr(tt.template)},
+			}, store).Generate(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(res.Output.([]byte)))
+		})
+	}
+
+	_, err = NewGoTemplate("", config.GoTemplateGenerator{
+		Template: config.StringOrValue{String: strPtr(`{{ ref "missing" }}`)},
+	}, store).Generate(context.Background())
+	assert.ErrorContains(t, err, `could not find reference "missing"`)
+}
+
+func TestGoTemplateMissingKey(t *testing.T) {
+	store := NewStore(nil)
+	var vars any
+	require.NoError(t, config.DecodeYAML([]byte("name: app\nlabels: {}\n"), &vars))
+	generate := func(missingKey, template string) (*Result, error) {
+		cfg := config.GoTemplateGenerator{
+			Template: config.StringOrValue{String: strPtr(template)},
+			Vars:     map[string]config.AnyOrValue{"config": {Any: &vars}},
+		}
+		if missingKey != "" {
+			cfg.MissingKey = config.StringOrValue{String: strPtr(missingKey)}
+		}
+		return NewGoTemplate("", cfg, store).Generate(context.Background())
+	}
+
+	_, err := generate("", `value: {{ .config.missing }}`)
+	assert.ErrorContains(t, err, `map has no entry for key "missing"`)
+
+	res, err := generate("zero", `value: {{ .config.missing }}`)
+	require.NoError(t, err)
+	assert.Equal(t, "value: ", string(res.Output.([]byte)))
+
+	res, err = generate("zero", `{{ define "label" }}[{{ .missing }}]{{ end }}{{ with .config }}{{ .name }}: {{ .labels.team }}{{ template "label" .labels }}{{ tpl "{{ .missing }}" .labels }}{{ end }}`)
+	require.NoError(t, err)
+	assert.Equal(t, "app: []", string(res.Output.([]byte)))
+
+	res, err = generate("default", `value: {{ .config.missing }}`)
+	require.NoError(t, err)
+	assert.Equal(t, "value: <no value>", string(res.Output.([]byte)))
+
+	_, err = generate("ignore", `value: {{ .config.missing }}`)
+	assert.ErrorContains(t, err, `invalid missingKey "ignore"`)
+}
+
+func TestDedent(t *testing.T) {
+	assert.Equal(t, "- a\n- b", dedent("    - a\n    - b"))
+	// The least indented line determines the indent, not the first.
+	assert.Equal(t, "    - a\n- b", dedent("        - a\n    - b"))
+	assert.Equal(t, "- a\n\n- b", dedent("    - a\n\n    - b"))
+	assert.Equal(t, "a: b\n    - c", dedent("a: b\n    - c"))
+}
@@ -27,34 +27,34 @@ func NewHelm(dir string, cfg config.HelmGenerator, refStore *Store) *Helm {
 	}
 }
 
-func (h *Helm) Generate(context.Context) (*Result, error) {
+func (h *Helm) Generate(ctx context.Context) (*Result, error) {
 	var buf bytes.Buffer
-	releaseName, err := h.refStore.GetStringValue(h.dir, h.cfg.ReleaseName)
+	releaseName, err := h.refStore.GetStringValue(ctx, h.dir, h.cfg.ReleaseName)
 	if err != nil {
 		return nil, err
 	}
-	chart, err := h.refStore.GetStringValue(h.dir, h.cfg.Chart)
+	chart, err := h.refStore.GetStringValue(ctx, h.dir, h.cfg.Chart)
 	if err != nil {
 		return nil, err
 	}
-	version, err := h.refStore.GetStringValue(h.dir, h.cfg.Version)
+	version, err := h.refStore.GetStringValue(ctx, h.dir, h.cfg.Version)
 	if err != nil {
 		return nil, err
 	}
-	repo, err := h.refStore.GetStringValue(h.dir, h.cfg.Repo)
+	repo, err := h.refStore.GetStringValue(ctx, h.dir, h.cfg.Repo)
 	if err != nil {
 		return nil, err
 	}
-	namespace, err := h.refStore.GetStringValue(h.dir, h.cfg.Namespace)
+	namespace, err := h.refStore.GetStringValue(ctx, h.dir, h.cfg.Namespace)
 	if err != nil {
 		return nil, err
 	}
-	includeCRDs, err := h.refStore.GetBoolValue(h.dir, h.cfg.IncludeCRDs)
+	includeCRDs, err := h.refStore.GetBoolValue(ctx, h.dir, h.cfg.IncludeCRDs)
 	if err != nil {
 		return nil, err
 	}
 
-	apiVersions, err := h.refStore.GetStringValueList(h.dir, h.cfg.APIVersions)
+	apiVersions, err := h.refStore.GetStringValueList(ctx, h.dir, h.cfg.APIVersions)
 	if err != nil {
 		return nil, err
 	}
@@ -81,7 +81,7 @@ func (h *Helm) Generate(context.Context) (*Result, error) {
 	}
 	var refs []string
 	for _, input := range h.cfg.Values {
-		ref, err := h.refStore.GetStringValue(h.dir, input)
+		ref, err := h.refStore.GetRawStringValue(ctx, h.dir, input)
 		if err != nil {
 			return nil, fmt.Errorf("error getting value: %w", err)
 		}
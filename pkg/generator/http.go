@@ -0,0 +1,311 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chancez/yamlforge/pkg/config"
+)
+
+var _ Generator = (*HTTP)(nil)
+
+// httpClient makes the requests of http generators and values. Requests time
+// out so that an unresponsive server can't block the pipeline forever.
+var httpClient = &http.Client{Timeout: 2 * time.Minute}
+
+type HTTP struct {
+	dir      string
+	cfg      config.HTTPGenerator
+	refStore *Store
+}
+
+func NewHTTP(dir string, cfg config.HTTPGenerator, refStore *Store) *HTTP {
+	return &HTTP{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+// httpResponse is a response stored in the cache.
+type httpResponse struct {
+	URL         string `json:"url"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+func (h *HTTP) Generate(ctx context.Context) (*Result, error) {
+	reqURL, err := h.refStore.GetStringValue(ctx, h.dir, h.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("error getting url: %w", err)
+	}
+	if reqURL == "" {
+		return nil, errors.New("url is required")
+	}
+	method, err := h.refStore.GetStringValue(ctx, h.dir, h.cfg.Method)
+	if err != nil {
+		return nil, fmt.Errorf("error getting method: %w", err)
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+	method = strings.ToUpper(method)
+	headers := make(http.Header)
+	for i, header := range h.cfg.Headers {
+		if header.Name == "" {
+			return nil, fmt.Errorf("headers[%d]: name cannot be empty", i)
+		}
+		val, err := h.refStore.GetValueBytes(ctx, h.dir, header.Value)
+		if err != nil {
+			return nil, fmt.Errorf("headers[%d]: error getting value: %w", i, err)
+		}
+		headers.Add(header.Name, strings.TrimSpace(string(val)))
+	}
+	body, err := h.refStore.GetRawStringValue(ctx, h.dir, h.cfg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error getting body: %w", err)
+	}
+	checksum, err := h.refStore.GetStringValue(ctx, h.dir, h.cfg.Checksum)
+	if err != nil {
+		return nil, fmt.Errorf("error getting checksum: %w", err)
+	}
+	if checksum != "" {
+		// Validate the checksum before making the request.
+		if _, err := computeChecksum(checksum, nil); err != nil {
+			return nil, err
+		}
+	}
+	format, err := h.refStore.GetStringValue(ctx, h.dir, h.cfg.Format)
+	if err != nil {
+		return nil, fmt.Errorf("error getting format: %w", err)
+	}
+
+	cache, err := h.refStore.GetBoolValue(ctx, h.dir, h.cfg.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("error getting cache: %w", err)
+	}
+	// Responses may contain credentials, so they're only cached if they're
+	// pinned by a checksum, or if caching is enabled explicitly.
+	cache = cache || checksum != ""
+
+	var cacheFile string
+	var resp *httpResponse
+	cacheErr := os.ErrNotExist
+	if cache {
+		cacheDir, err := h.refStore.getCacheDir("http")
+		if err != nil {
+			return nil, err
+		}
+		cacheFile = filepath.Join(cacheDir, httpCacheKey(method, reqURL, headers, body)+".json")
+		resp, cacheErr = readHTTPCache(cacheFile)
+	}
+	fetched := false
+	switch {
+	case h.refStore.offline:
+		// The error doesn't wrap os.ErrNotExist, so a missing cache entry
+		// isn't ignored like a missing response.
+		if errors.Is(cacheErr, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s %s is not cached, cannot make request while offline", method, reqURL)
+		}
+		if cacheErr != nil {
+			return nil, fmt.Errorf("%s %s: error reading cache: %v", method, reqURL, cacheErr)
+		}
+	case cacheErr == nil && checksum != "" && verifyChecksum(checksum, resp.Body) == nil:
+		// The cached response is the expected response, so there's no need to
+		// make the request again.
+	default:
+		resp, err = doHTTPRequest(ctx, method, reqURL, headers, body)
+		if err != nil {
+			return nil, err
+		}
+		fetched = true
+	}
+
+	if checksum != "" {
+		if err := verifyChecksum(checksum, resp.Body); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", method, reqURL, err)
+		}
+	}
+	if fetched && cache {
+		if err := writeHTTPCache(cacheFile, resp); err != nil {
+			return nil, fmt.Errorf("error caching response: %w", err)
+		}
+	}
+
+	if format == "" {
+		format = formatFromContentType(resp.ContentType)
+	}
+	if format == "" {
+		if u, err := url.Parse(reqURL); err == nil {
+			format = formatFromFileName(u.Path)
+		}
+	}
+	return &Result{Output: resp.Body, Format: format}, nil
+}
+
+func doHTTPRequest(ctx context.Context, method, reqURL string, headers http.Header, body string) (*httpResponse, error) {
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header = headers
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s %s: %s: %w", method, reqURL, res.Status, os.ErrNotExist)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s: unexpected status %s", method, reqURL, res.Status)
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	return &httpResponse{
+		URL:         reqURL,
+		ContentType: res.Header.Get("Content-Type"),
+		Body:        data,
+	}, nil
+}
+
+// httpCacheKey returns a key identifying a request. Headers are included so
+// that requests with different credentials or content negotiation aren't
+// shared, but the key is hashed to avoid storing credentials in file names.
+func httpCacheKey(method, reqURL string, headers http.Header, body string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", method, reqURL)
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, val := range headers[name] {
+			fmt.Fprintf(h, "%s: %s\n", name, val)
+		}
+	}
+	fmt.Fprintf(h, "\n%s", body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func readHTTPCache(file string) (*httpResponse, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var resp httpResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("error parsing cached response: %w", err)
+	}
+	return &resp, nil
+}
+
+func writeHTTPCache(file string, resp *httpResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+		return err
+	}
+	// Write to a temporary file and rename it so that concurrent runs never
+	// read a partially written response.
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// computeChecksum returns the checksum of data using the algorithm of the
+// expected checksum, which is in the form <algorithm>:<hex>.
+func computeChecksum(expected string, data []byte) (string, error) {
+	algo, _, ok := strings.Cut(expected, ":")
+	if !ok {
+		return "", fmt.Errorf("invalid checksum %q, must be in the form sha256:<hex> or sha512:<hex>", expected)
+	}
+	var h hash.Hash
+	switch algo {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("invalid checksum algorithm %q, must be sha256 or sha512", algo)
+	}
+	h.Write(data)
+	return algo + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyChecksum returns an error if the checksum of data doesn't match the
+// expected checksum.
+func verifyChecksum(expected string, data []byte) error {
+	actual, err := computeChecksum(expected, data)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum mismatch, expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// formatFromContentType returns the name of the format of a Content-Type
+// header, or an empty string if it's unknown.
+func formatFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case "application/json":
+		return "json"
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return "yaml"
+	case "application/toml":
+		return "toml"
+	case "application/xml", "text/xml":
+		return "xml"
+	case "text/csv":
+		return "csv"
+	}
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return "json"
+	case strings.HasSuffix(mediaType, "+yaml"):
+		return "yaml"
+	case strings.HasSuffix(mediaType, "+xml"):
+		return "xml"
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/values":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = io.WriteString(w, `{"name": "app"}`)
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = io.WriteString(w, r.Method+" "+string(body))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	store := NewStore(map[string]any{"token": "Bearer secret"})
+	cacheDir := t.TempDir()
+	store.SetCache(cacheDir, false)
+	generate := func(cfg config.HTTPGenerator) (*Result, error) {
+		t.Helper()
+		return NewHTTP("", cfg, store).Generate(context.Background())
+	}
+	token := config.NamedValue{
+		Name:  "Authorization",
+		Value: config.Value{Var: "token"},
+	}
+
+	valuesURL := server.URL + "/values"
+	enabled := config.BoolOrValue{Bool: boolPtr(true)}
+	res, err := generate(config.HTTPGenerator{
+		URL:     config.StringOrValue{String: &valuesURL},
+		Headers: []config.NamedValue{token},
+		Cache:   enabled,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "app"}`, string(res.Output.([]byte)))
+	assert.Equal(t, "json", res.Format)
+
+	_, err = generate(config.HTTPGenerator{URL: config.StringOrValue{String: &valuesURL}})
+	assert.ErrorContains(t, err, "unexpected status 401 Unauthorized")
+
+	method := "post"
+	body := "hello"
+	echoURL := server.URL + "/echo"
+	res, err = generate(config.HTTPGenerator{
+		URL:    config.StringOrValue{String: &echoURL},
+		Method: config.StringOrValue{String: &method},
+		Body:   config.StringOrValue{String: &body},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "POST hello", string(res.Output.([]byte)))
+	assert.Equal(t, "", res.Format)
+
+	checksum := "sha256:" + strings.Repeat("0", 64)
+	correct, err := computeChecksum("sha256:", []byte(`{"name": "app"}`))
+	require.NoError(t, err)
+	_, err = generate(config.HTTPGenerator{
+		URL:      config.StringOrValue{String: &valuesURL},
+		Headers:  []config.NamedValue{token},
+		Checksum: config.StringOrValue{String: &checksum},
+	})
+	assert.ErrorContains(t, err, "checksum mismatch, expected "+checksum+", got "+correct)
+	// Responses matching the checksum are read from the cache.
+	before := requests
+	res, err = generate(config.HTTPGenerator{
+		URL:      config.StringOrValue{String: &valuesURL},
+		Headers:  []config.NamedValue{token},
+		Checksum: config.StringOrValue{String: &correct},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "app"}`, string(res.Output.([]byte)))
+	assert.Equal(t, before, requests)
+
+	// 404 responses are missing values.
+	missingURL := server.URL + "/missing.yaml"
+	res, err = store.GetValue(context.Background(), "", config.Value{
+		HTTP:          &config.HTTPGenerator{URL: config.StringOrValue{String: &missingURL}},
+		IgnoreMissing: true,
+		Default:       "default",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "default", res.Output)
+
+	// Offline, only cached responses are returned.
+	server.Close()
+	store.SetCache(cacheDir, true)
+	res, err = generate(config.HTTPGenerator{
+		URL:     config.StringOrValue{String: &valuesURL},
+		Headers: []config.NamedValue{token},
+		Cache:   enabled,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "app"}`, string(res.Output.([]byte)))
+	// Responses without a checksum or cache enabled aren't cached.
+	_, err = generate(config.HTTPGenerator{
+		URL:    config.StringOrValue{String: &echoURL},
+		Method: config.StringOrValue{String: &method},
+		Body:   config.StringOrValue{String: &body},
+		Cache:  enabled,
+	})
+	assert.ErrorContains(t, err, "POST "+echoURL+" is not cached, cannot make request while offline")
+	// Uncached responses aren't missing values.
+	_, err = store.GetValue(context.Background(), "", config.Value{
+		HTTP:          &config.HTTPGenerator{URL: config.StringOrValue{String: &missingURL}},
+		IgnoreMissing: true,
+		Default:       "default",
+	})
+	assert.ErrorContains(t, err, "GET "+missingURL+" is not cached, cannot make request while offline")
+	entries, err := os.ReadDir(filepath.Join(cacheDir, "http"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFormatFromContentType(t *testing.T) {
+	assert.Equal(t, "json", formatFromContentType("application/json"))
+	assert.Equal(t, "json", formatFromContentType("application/vnd.api+json; charset=utf-8"))
+	assert.Equal(t, "yaml", formatFromContentType("application/x-yaml"))
+	assert.Equal(t, "toml", formatFromContentType("application/toml"))
+	assert.Equal(t, "", formatFromContentType("text/plain"))
+	assert.Equal(t, "", formatFromContentType(""))
+}
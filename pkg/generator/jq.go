@@ -27,8 +27,8 @@ func NewJQ(dir string, cfg config.JQGenerator, refStore *Store) *JQ {
 	}
 }
 
-func (jq *JQ) Generate(context.Context) (*Result, error) {
-	expr, err := jq.refStore.GetStringValue(jq.dir, jq.cfg.Expr)
+func (jq *JQ) Generate(ctx context.Context) (*Result, error) {
+	expr, err := jq.refStore.GetRawStringValue(ctx, jq.dir, jq.cfg.Expr)
 	if err != nil {
 		return nil, fmt.Errorf("error getting expression: %w", err)
 	}
@@ -36,7 +36,7 @@ func (jq *JQ) Generate(context.Context) (*Result, error) {
 		expr,
 	}
 
-	slurp, err := jq.refStore.GetBoolValue(jq.dir, jq.cfg.Slurp)
+	slurp, err := jq.refStore.GetBoolValue(ctx, jq.dir, jq.cfg.Slurp)
 	if err != nil {
 		return nil, fmt.Errorf("error getting slurp: %w", err)
 	}
@@ -50,7 +50,7 @@ func (jq *JQ) Generate(context.Context) (*Result, error) {
 		"--monochrome-output",
 	)
 
-	data, err := jq.refStore.GetStringValue(jq.dir, jq.cfg.Input)
+	data, err := jq.refStore.GetRawStringValue(ctx, jq.dir, jq.cfg.Input)
 	if err != nil {
 		return nil, fmt.Errorf("error getting value: %w", err)
 	}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/chancez/yamlforge/pkg/config"
@@ -26,14 +28,33 @@ func NewJSON(dir string, cfg config.JSONGenerator, refStore *Store) *JSON {
 	}
 }
 
-func (j *JSON) Generate(context.Context) (*Result, error) {
-	var out bytes.Buffer
-	enc := json.NewEncoder(&out)
-	if j.cfg.Indent != 0 {
-		enc.SetIndent("", strings.Repeat(" ", j.cfg.Indent))
+func (j *JSON) Generate(ctx context.Context) (*Result, error) {
+	mode, err := j.refStore.GetStringValue(ctx, j.dir, j.cfg.Mode)
+	if err != nil {
+		return nil, fmt.Errorf("error getting mode: %w", err)
+	}
+	switch mode {
+	case "":
+		mode = "stream"
+	case "stream", "array", "lines":
+	default:
+		return nil, fmt.Errorf("invalid mode %q, must be one of stream, array or lines", mode)
+	}
+	canonical, err := j.refStore.GetBoolValue(ctx, j.dir, j.cfg.Canonical)
+	if err != nil {
+		return nil, fmt.Errorf("error getting canonical: %w", err)
+	}
+	disableHTMLEscape, err := j.refStore.GetBoolValue(ctx, j.dir, j.cfg.DisableHTMLEscape)
+	if err != nil {
+		return nil, fmt.Errorf("error getting disableHTMLEscape: %w", err)
 	}
+	if j.cfg.Indent != 0 && (mode == "lines" || canonical) {
+		return nil, errors.New("indent cannot be used with the lines mode or canonical")
+	}
+
+	docs := []any{}
 	for _, input := range j.cfg.Input {
-		vals, err := j.refStore.GetParsedValues(j.dir, input)
+		vals, err := j.refStore.GetParsedValues(ctx, j.dir, input)
 		if err != nil {
 			return nil, fmt.Errorf("error getting value: %w", err)
 		}
@@ -41,11 +62,42 @@ func (j *JSON) Generate(context.Context) (*Result, error) {
 			if err != nil {
 				return nil, fmt.Errorf("error while processing input: %w", err)
 			}
-			err = enc.Encode(val.Parsed())
+			docs = append(docs, val.Parsed())
+		}
+	}
+
+	var out bytes.Buffer
+	encode := func(v any) error {
+		if canonical {
+			data, err := canonicalJSON(v)
 			if err != nil {
-				return nil, fmt.Errorf("error writing JSON: %w", err)
+				return err
 			}
+			out.Write(data)
+			out.WriteString("\n")
+			return nil
+		}
+		return j.newEncoder(&out, !disableHTMLEscape).Encode(v)
+	}
+	if mode == "array" {
+		if err := encode(docs); err != nil {
+			return nil, fmt.Errorf("error writing JSON: %w", err)
+		}
+		return &Result{Output: out.Bytes(), Format: "json"}, nil
+	}
+	for _, doc := range docs {
+		if err := encode(doc); err != nil {
+			return nil, fmt.Errorf("error writing JSON: %w", err)
 		}
 	}
 	return &Result{Output: out.Bytes(), Format: "json"}, nil
 }
+
+func (j *JSON) newEncoder(w io.Writer, escapeHTML bool) *json.Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(escapeHTML)
+	if j.cfg.Indent != 0 {
+		enc.SetIndent("", strings.Repeat(" ", j.cfg.Indent))
+	}
+	return enc
+}
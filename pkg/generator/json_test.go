@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONModes(t *testing.T) {
+	store := NewStore(nil)
+	err := store.AddReference("docs", &Result{Output: []byte("b: <x>\na: 1\n---\nc: true\n"), Format: "yaml"})
+	require.NoError(t, err)
+	input := []config.Value{{Ref: "docs"}}
+
+	tests := []struct {
+		name    string
+		cfg     config.JSONGenerator
+		want    string
+		wantErr string
+	}{
+		{
+			name: "stream",
+			cfg:  config.JSONGenerator{Input: input},
+			want: "{\"a\":1,\"b\":\"\\u003cx\\u003e\"}\n{\"c\":true}\n",
+		},
+		{
+			name: "array",
+			cfg:  config.JSONGenerator{Input: input, Mode: config.StringOrValue{String: strPtr("array")}, DisableHTMLEscape: config.BoolOrValue{Bool: boolPtr(true)}},
+			want: "[{\"a\":1,\"b\":\"<x>\"},{\"c\":true}]\n",
+		},
+		{
+			name: "canonical array",
+			cfg:  config.JSONGenerator{Input: input, Mode: config.StringOrValue{String: strPtr("array")}, Canonical: config.BoolOrValue{Bool: boolPtr(true)}},
+			want: "[{\"a\":1,\"b\":\"<x>\"},{\"c\":true}]\n",
+		},
+		{
+			name:    "lines with indent",
+			cfg:     config.JSONGenerator{Input: input, Mode: config.StringOrValue{String: strPtr("lines")}, Indent: 2},
+			wantErr: "indent cannot be used with the lines mode or canonical",
+		},
+		{
+			name:    "invalid mode",
+			cfg:     config.JSONGenerator{Input: input, Mode: config.StringOrValue{String: strPtr("csv")}},
+			wantErr: `invalid mode "csv", must be one of stream, array or lines`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewJSON("", tt.cfg, store).Generate(context.Background())
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(res.Output.([]byte)))
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/google/go-jsonnet"
+)
+
+var _ Generator = (*Jsonnet)(nil)
+
+type Jsonnet struct {
+	dir      string
+	cfg      config.JsonnetGenerator
+	refStore *Store
+}
+
+func NewJsonnet(dir string, cfg config.JsonnetGenerator, refStore *Store) *Jsonnet {
+	return &Jsonnet{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+func (j *Jsonnet) Generate(ctx context.Context) (*Result, error) {
+	snippet, err := j.refStore.GetRawStringValue(ctx, j.dir, j.cfg.Snippet)
+	if err != nil {
+		return nil, fmt.Errorf("error getting snippet: %w", err)
+	}
+	// The file name is used to resolve relative imports.
+	filename := filepath.Join(j.dir, "snippet.jsonnet")
+	if j.cfg.Snippet.Value != nil && j.cfg.Snippet.Value.File != "" {
+		filename = filepath.Join(j.dir, j.cfg.Snippet.Value.File)
+	}
+	jpaths, err := j.refStore.GetStringValueList(ctx, j.dir, j.cfg.JPath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting jpath: %w", err)
+	}
+	for i, jpath := range jpaths {
+		jpaths[i] = filepath.Join(j.dir, jpath)
+	}
+	stream, err := j.refStore.GetBoolValue(ctx, j.dir, j.cfg.Stream)
+	if err != nil {
+		return nil, fmt.Errorf("error getting stream: %w", err)
+	}
+	format, err := j.refStore.GetStringValue(ctx, j.dir, j.cfg.Format)
+	if err != nil {
+		return nil, fmt.Errorf("error getting format: %w", err)
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(&jsonnet.FileImporter{JPaths: jpaths})
+	for i, extVar := range j.cfg.ExtVars {
+		val, isCode, err := j.getVariable(ctx, extVar)
+		if err != nil {
+			return nil, fmt.Errorf("extVars[%d]: %w", i, err)
+		}
+		if isCode {
+			vm.ExtCode(extVar.Name, val)
+		} else {
+			vm.ExtVar(extVar.Name, val)
+		}
+	}
+	for i, tla := range j.cfg.TLAs {
+		val, isCode, err := j.getVariable(ctx, tla)
+		if err != nil {
+			return nil, fmt.Errorf("tlas[%d]: %w", i, err)
+		}
+		if isCode {
+			vm.TLACode(tla.Name, val)
+		} else {
+			vm.TLAVar(tla.Name, val)
+		}
+	}
+
+	out, err := vm.EvaluateAnonymousSnippet(filename, snippet)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating jsonnet: %w", err)
+	}
+	var result any
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		return nil, fmt.Errorf("error parsing jsonnet output: %w", err)
+	}
+	switch v := result.(type) {
+	case string:
+		if format == "" && stream {
+			format = "yaml"
+		}
+		return &Result{Output: []byte(v), Format: format}, nil
+	case []any:
+		if stream {
+			return &Result{Output: v}, nil
+		}
+	default:
+		if stream {
+			return nil, errors.New("stream is set, but jsonnet did not evaluate to an array")
+		}
+	}
+	return &Result{Output: []byte(out), Format: "json"}, nil
+}
+
+// getVariable returns the value of an external variable or top-level argument,
+// and whether it's Jsonnet code rather than a string.
+func (j *Jsonnet) getVariable(ctx context.Context, v config.NamedValue) (string, bool, error) {
+	if v.Name == "" {
+		return "", false, fmt.Errorf("name cannot be empty")
+	}
+	res, err := j.refStore.GetValue(ctx, j.dir, v.Value)
+	if err != nil {
+		return "", false, fmt.Errorf("error getting value: %w", err)
+	}
+	val, err := parseReference(res)
+	if err != nil {
+		return "", false, fmt.Errorf("error parsing value: %w", err)
+	}
+	if s, ok := val.(string); ok {
+		return s, false, nil
+	}
+	// JSON is valid Jsonnet.
+	data, err := json.Marshal(val)
+	if err != nil {
+		return "", false, fmt.Errorf("error encoding value: %w", err)
+	}
+	return string(data), true, nil
+}
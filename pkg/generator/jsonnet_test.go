@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJsonnet(t *testing.T) {
+	store := NewStore(nil)
+	err := store.AddReference("settings", &Result{
+		Format: "yaml",
+		Output: []byte("replicas: 3\n"),
+	})
+	require.NoError(t, err)
+
+	generate := func(cfg config.JsonnetGenerator) (*Result, error) {
+		return NewJsonnet("", cfg, store).Generate(context.Background())
+	}
+	enabled := config.BoolOrValue{Bool: boolPtr(true)}
+	var name any = "app"
+
+	res, err := generate(config.JsonnetGenerator{
+		Snippet: config.StringOrValue{String: strPtr(`function(settings) { name: std.extVar("name"), replicas: settings.replicas }`)},
+		ExtVars: []config.NamedValue{{Name: "name", Value: config.Value{Value: &config.AnyOrValue{Any: &name}}}},
+		TLAs:    []config.NamedValue{{Name: "settings", Value: config.Value{Ref: "settings"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "json", res.Format)
+	assert.JSONEq(t, `{"name": "app", "replicas": 3}`, string(res.Output.([]byte)))
+
+	res, err = generate(config.JsonnetGenerator{
+		Snippet: config.StringOrValue{String: strPtr(`[{a: 1}, {b: 2}]`)},
+		Stream:  enabled,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"a": float64(1)}, map[string]any{"b": float64(2)}}, res.Output)
+
+	res, err = generate(config.JsonnetGenerator{
+		Snippet: config.StringOrValue{String: strPtr(`std.manifestYamlStream([{a: 1}, {b: 2}])`)},
+		Stream:  enabled,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "yaml", res.Format)
+	assert.Equal(t, "---\n\"a\": 1\n---\n\"b\": 2\n...\n", string(res.Output.([]byte)))
+
+	// Strings are returned as text unless a format is set.
+	res, err = generate(config.JsonnetGenerator{
+		Snippet: config.StringOrValue{String: strPtr(`"a: %d" % 1`)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "", res.Format)
+	assert.Equal(t, "a: 1", string(res.Output.([]byte)))
+
+	res, err = generate(config.JsonnetGenerator{
+		Snippet: config.StringOrValue{String: strPtr(`std.manifestYamlDoc({a: 1})`)},
+		Format:  config.StringOrValue{String: strPtr("yaml")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "yaml", res.Format)
+	assert.Equal(t, "\"a\": 1", string(res.Output.([]byte)))
+
+	_, err = generate(config.JsonnetGenerator{
+		Snippet: config.StringOrValue{String: strPtr(`{a: 1}`)},
+		Stream:  enabled,
+	})
+	assert.ErrorContains(t, err, "stream is set, but jsonnet did not evaluate to an array")
+}
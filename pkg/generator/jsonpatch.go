@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/chancez/yamlforge/pkg/yamldoc"
 	jsonpatch "github.com/evanphx/json-patch/v5"
 )
 
@@ -25,18 +26,18 @@ func NewJSONPatch(dir string, cfg config.JSONPatchGenerator, refStore *Store) *J
 	}
 }
 
-func (jp *JSONPatch) Generate(context.Context) (*Result, error) {
-	input, err := jp.refStore.GetStringValue(jp.dir, jp.cfg.Input)
+func (jp *JSONPatch) Generate(ctx context.Context) (*Result, error) {
+	input, err := jp.refStore.GetRawStringValue(ctx, jp.dir, jp.cfg.Input)
 	if err != nil {
 		return nil, fmt.Errorf("error getting input: %w", err)
 	}
 
-	patch, err := jp.refStore.GetStringValue(jp.dir, jp.cfg.Patch)
+	patch, err := jp.refStore.GetRawStringValue(ctx, jp.dir, jp.cfg.Patch)
 	if err != nil {
 		return nil, fmt.Errorf("error getting patch: %w", err)
 	}
 
-	merge, err := jp.refStore.GetBoolValue(jp.dir, jp.cfg.Merge)
+	merge, err := jp.refStore.GetBoolValue(ctx, jp.dir, jp.cfg.Merge)
 	if err != nil {
 		return nil, fmt.Errorf("error getting merge: %w", err)
 	}
@@ -53,28 +54,75 @@ func (jp *JSONPatch) Generate(context.Context) (*Result, error) {
 		}
 	}
 
-	inputBytes := []byte(input)
+	preserve, err := jp.refStore.GetBoolValue(ctx, jp.dir, jp.cfg.Preserve)
+	if err != nil {
+		return nil, fmt.Errorf("error getting preserve: %w", err)
+	}
+	if preserve {
+		return jp.generatePreserved(input, configPatch, merge)
+	}
+
+	modified, err := applyPatch([]byte(input), configPatch, merge)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Output: modified, Format: "json"}, nil
+}
 
+// generatePreserved applies the patch to the YAML input, only updating the
+// values modified by the patch.
+func (jp *JSONPatch) generatePreserved(input string, patch []byte, merge bool) (*Result, error) {
+	doc, err := yamldoc.Parse([]byte(input))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing input: %w", err)
+	}
+	vals, err := doc.Values()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing input: %w", err)
+	}
+	if len(vals) != 1 {
+		return nil, fmt.Errorf("input must contain a single document, got %d", len(vals))
+	}
+	inputBytes, err := json.Marshal(vals[0])
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert input to JSON: %w", err)
+	}
+	modified, err := applyPatch(inputBytes, patch, merge)
+	if err != nil {
+		return nil, err
+	}
+	var modifiedVal any
+	if err := json.Unmarshal(modified, &modifiedVal); err != nil {
+		return nil, fmt.Errorf("error parsing patched input: %w", err)
+	}
+	if err := doc.Update([]any{modifiedVal}); err != nil {
+		return nil, fmt.Errorf("error updating input: %w", err)
+	}
+	return &Result{Output: doc.Bytes(), Format: "yaml"}, nil
+}
+
+// applyPatch applies a JSON patch, or a JSON merge patch if merge is true, to
+// input.
+func applyPatch(input, patch []byte, merge bool) ([]byte, error) {
 	if merge {
-		modified, err := jsonpatch.MergeMergePatches(inputBytes, configPatch)
+		modified, err := jsonpatch.MergeMergePatches(input, patch)
 		if err != nil {
 			return nil, fmt.Errorf("error applying patch: %w", err)
 		}
-		return &Result{Output: modified, Format: "json"}, nil
+		return modified, nil
 	}
 
-	decodedPatch, err := jsonpatch.DecodePatch(configPatch)
+	decodedPatch, err := jsonpatch.DecodePatch(patch)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing JSON patch: %w", err)
 	}
 
-	modified, err := decodedPatch.ApplyWithOptions(inputBytes, &jsonpatch.ApplyOptions{
+	modified, err := decodedPatch.ApplyWithOptions(input, &jsonpatch.ApplyOptions{
 		EnsurePathExistsOnAdd:  true,
 		SupportNegativeIndices: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error applying patch: %w", err)
 	}
-
-	return &Result{Output: modified, Format: "json"}, nil
+	return modified, nil
 }
@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/chancez/yamlforge/pkg/config"
+)
+
+var _ Generator = (*JSONSchema)(nil)
+
+type JSONSchema struct {
+	dir      string
+	cfg      config.JSONSchemaGenerator
+	refStore *Store
+}
+
+func NewJSONSchema(dir string, cfg config.JSONSchemaGenerator, refStore *Store) *JSONSchema {
+	return &JSONSchema{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+func (js *JSONSchema) Generate(ctx context.Context) (*Result, error) {
+	schemaRes, err := js.refStore.GetAnyValue(ctx, js.dir, js.cfg.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("error getting schema: %w", err)
+	}
+	if schemaRes == nil {
+		return nil, errors.New("schema is required")
+	}
+	schemaDocs, err := parseResult(schemaRes, "yaml")
+	if err != nil {
+		return nil, fmt.Errorf("error parsing schema: %w", err)
+	}
+	var schemaDoc any
+	for doc, err := range schemaDocs {
+		if err != nil {
+			return nil, fmt.Errorf("error parsing schema: %w", err)
+		}
+		schemaDoc = doc.Parsed()
+		break
+	}
+
+	// Schemas read from files use their path as their URL, so that references
+	// to other schemas are resolved relative to the schema.
+	url := "yamlforge:///schema.json"
+	if js.cfg.Schema.Value != nil && js.cfg.Schema.Value.File != "" {
+		url, err = filepath.Abs(filepath.Join(js.dir, js.cfg.Schema.Value.File))
+		if err != nil {
+			return nil, fmt.Errorf("error getting schema path: %w", err)
+		}
+	}
+	sch, err := compileSchema(url, schemaDoc)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling schema: %w", err)
+	}
+
+	res, err := js.refStore.GetValue(ctx, js.dir, js.cfg.Input)
+	if err != nil {
+		return nil, fmt.Errorf("error getting input: %w", err)
+	}
+	vals, err := parseResult(res, "yaml")
+	if err != nil {
+		return nil, fmt.Errorf("error parsing input: %w", err)
+	}
+	type docViolation struct {
+		doc       int
+		violation schemaViolation
+	}
+	var violations []docViolation
+	docCount := 0
+	for val, err := range vals {
+		if err != nil {
+			return nil, fmt.Errorf("error while processing input: %w", err)
+		}
+		docViolations, err := validateSchema(sch, val.Parsed())
+		if err != nil {
+			return nil, fmt.Errorf("error validating input: %w", err)
+		}
+		for _, violation := range docViolations {
+			violations = append(violations, docViolation{doc: docCount, violation: violation})
+		}
+		docCount++
+	}
+	if len(violations) != 0 {
+		var msgs []string
+		for _, v := range violations {
+			// Only identify the document when validating multiple documents.
+			if docCount > 1 {
+				msgs = append(msgs, fmt.Sprintf("document[%d]: %s", v.doc, v.violation))
+			} else {
+				msgs = append(msgs, v.violation.String())
+			}
+		}
+		return nil, fmt.Errorf("input does not match schema:\n%s", strings.Join(msgs, "\n"))
+	}
+	return res, nil
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	store := NewStore(nil)
+	err := store.AddReference("configs", &Result{
+		Format: "yaml",
+		Output: []byte(`
+name: valid
+port: 8080
+---
+name: invalid
+port: "8080"
+---
+port: 0
+`),
+	})
+	require.NoError(t, err)
+
+	var schema any = map[string]any{
+		"type":     "object",
+		"required": []any{"name"},
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"port": map[string]any{"type": "integer", "minimum": 1},
+		},
+	}
+	_, err = NewJSONSchema("", config.JSONSchemaGenerator{
+		Input:  config.Value{Ref: "configs"},
+		Schema: config.AnyOrValue{Any: &schema},
+	}, store).Generate(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, `input does not match schema:
+document[1]: /port: got string, want integer
+document[2]: /: missing property 'name'
+document[2]: /port: minimum: got 0, want 1`, err.Error())
+
+	var valid any = map[string]any{"name": "valid"}
+	res, err := NewJSONSchema("", config.JSONSchemaGenerator{
+		Input:  config.Value{Value: &config.AnyOrValue{Any: &valid}},
+		Schema: config.AnyOrValue{Any: &schema},
+	}, store).Generate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, valid, res.Output, "input should be returned unaltered")
+}
@@ -0,0 +1,284 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/chancez/yamlforge/pkg/k8s"
+)
+
+var _ Generator = (*Kubernetes)(nil)
+
+type Kubernetes struct {
+	dir      string
+	cfg      config.KubernetesGenerator
+	refStore *Store
+}
+
+func NewKubernetes(dir string, cfg config.KubernetesGenerator, refStore *Store) *Kubernetes {
+	return &Kubernetes{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+type imageOverride struct {
+	name    string
+	newName string
+	newTag  string
+	digest  string
+}
+
+type kindName struct {
+	kind string
+	name string
+}
+
+func (k *Kubernetes) Generate(ctx context.Context) (*Result, error) {
+	namespace, err := k.refStore.GetStringValue(ctx, k.dir, k.cfg.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting namespace: %w", err)
+	}
+	labels, err := k.getStringMap(ctx, k.cfg.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("error getting labels: %w", err)
+	}
+	includeSelectors, err := k.refStore.GetBoolValue(ctx, k.dir, k.cfg.IncludeSelectors)
+	if err != nil {
+		return nil, fmt.Errorf("error getting includeSelectors: %w", err)
+	}
+	annotations, err := k.getStringMap(ctx, k.cfg.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("error getting annotations: %w", err)
+	}
+	namePrefix, err := k.refStore.GetStringValue(ctx, k.dir, k.cfg.NamePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("error getting namePrefix: %w", err)
+	}
+	nameSuffix, err := k.refStore.GetStringValue(ctx, k.dir, k.cfg.NameSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("error getting nameSuffix: %w", err)
+	}
+	var images []imageOverride
+	for i, image := range k.cfg.Images {
+		if image.Name == "" {
+			return nil, fmt.Errorf("images[%d]: name cannot be empty", i)
+		}
+		override := imageOverride{name: image.Name}
+		override.newName, err = k.refStore.GetStringValue(ctx, k.dir, image.NewName)
+		if err != nil {
+			return nil, fmt.Errorf("images[%d]: error getting newName: %w", i, err)
+		}
+		override.newTag, err = k.refStore.GetStringValue(ctx, k.dir, image.NewTag)
+		if err != nil {
+			return nil, fmt.Errorf("images[%d]: error getting newTag: %w", i, err)
+		}
+		override.digest, err = k.refStore.GetStringValue(ctx, k.dir, image.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("images[%d]: error getting digest: %w", i, err)
+		}
+		images = append(images, override)
+	}
+
+	var docs []any
+	var objs []map[string]any
+	for _, input := range k.cfg.Input {
+		vals, err := k.refStore.GetParsedValues(ctx, k.dir, input)
+		if err != nil {
+			return nil, fmt.Errorf("error getting value: %w", err)
+		}
+		for val, err := range vals {
+			if err != nil {
+				return nil, fmt.Errorf("error while processing input: %w", err)
+			}
+			if val.Parsed() == nil {
+				continue
+			}
+			// Copy the input to avoid modifying the output of other stages.
+			doc := deepCopy(val.Parsed())
+			if obj, ok := doc.(map[string]any); ok {
+				objs = append(objs, obj)
+			}
+			docs = append(docs, doc)
+		}
+	}
+
+	clusterScopedCRDKinds := k8s.ClusterScopedCRDKinds(objs)
+	serviceAccounts := make(map[string]bool)
+	for _, obj := range objs {
+		if obj["kind"] == "ServiceAccount" {
+			if name, ok := k8s.NestedMap(obj, "metadata")["name"].(string); ok {
+				serviceAccounts[name] = true
+			}
+		}
+	}
+	renames := make(map[kindName]string)
+	for _, obj := range objs {
+		kind, _ := obj["kind"].(string)
+		if kind == "" {
+			continue
+		}
+		if len(images) != 0 {
+			overrideImages(obj, images)
+		}
+		if len(labels) != 0 {
+			addLabels(obj, labels, includeSelectors)
+		}
+		if len(annotations) != 0 {
+			addAnnotations(obj, annotations)
+		}
+		if namespace != "" && !k8s.IsClusterScoped(kind) && !clusterScopedCRDKinds[kind] {
+			k8s.EnsureNestedMap(obj, "metadata")["namespace"] = namespace
+		}
+		if namespace != "" && (kind == "RoleBinding" || kind == "ClusterRoleBinding") {
+			// Subjects referencing service accounts being moved to the new
+			// namespace must be updated too.
+			for _, subject := range k8s.NestedMaps(obj, "subjects") {
+				name, _ := subject["name"].(string)
+				if subject["kind"] == "ServiceAccount" && serviceAccounts[name] {
+					subject["namespace"] = namespace
+				}
+			}
+		}
+		if namePrefix != "" || nameSuffix != "" {
+			metadata := k8s.NestedMap(obj, "metadata")
+			name, _ := metadata["name"].(string)
+			// Names of these kinds have a required format, or are
+			// referenced by namespaced resources, so they are not renamed.
+			if name != "" && kind != "CustomResourceDefinition" && kind != "APIService" && kind != "Namespace" {
+				renames[kindName{kind: kind, name: name}] = namePrefix + name + nameSuffix
+			}
+		}
+	}
+
+	if len(renames) != 0 {
+		for _, obj := range objs {
+			k8s.UpdateReferences(obj, func(kind, name string) string {
+				if newName, ok := renames[kindName{kind: kind, name: name}]; ok {
+					return newName
+				}
+				return name
+			})
+			kind, _ := obj["kind"].(string)
+			metadata := k8s.NestedMap(obj, "metadata")
+			name, _ := metadata["name"].(string)
+			if newName, ok := renames[kindName{kind: kind, name: name}]; ok {
+				metadata["name"] = newName
+			}
+		}
+	}
+
+	return &Result{Output: docs}, nil
+}
+
+func (k *Kubernetes) getStringMap(ctx context.Context, val config.MapOrValue) (map[string]string, error) {
+	m, err := k.refStore.GetMapValue(ctx, k.dir, val)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]string, len(m))
+	for key, v := range m {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of %q must be a string, got %T", key, v)
+		}
+		ret[key] = s
+	}
+	return ret, nil
+}
+
+func addLabels(obj map[string]any, labels map[string]string, includeSelectors bool) {
+	targets := []map[string]any{
+		k8s.EnsureNestedMap(obj, "metadata", "labels"),
+	}
+	if template := k8s.PodTemplate(obj); template != nil {
+		targets = append(targets, k8s.EnsureNestedMap(template, "metadata", "labels"))
+	}
+	if includeSelectors {
+		switch obj["kind"] {
+		case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet":
+			targets = append(targets, k8s.NestedMap(obj, "spec", "selector", "matchLabels"))
+		case "Service", "ReplicationController":
+			targets = append(targets, k8s.NestedMap(obj, "spec", "selector"))
+		}
+	}
+	for _, target := range targets {
+		if target == nil {
+			continue
+		}
+		for key, val := range labels {
+			target[key] = val
+		}
+	}
+}
+
+func addAnnotations(obj map[string]any, annotations map[string]string) {
+	targets := []map[string]any{
+		k8s.EnsureNestedMap(obj, "metadata", "annotations"),
+	}
+	if template := k8s.PodTemplate(obj); template != nil {
+		targets = append(targets, k8s.EnsureNestedMap(template, "metadata", "annotations"))
+	}
+	for _, target := range targets {
+		if target == nil {
+			continue
+		}
+		for key, val := range annotations {
+			target[key] = val
+		}
+	}
+}
+
+func overrideImages(obj map[string]any, overrides []imageOverride) {
+	podSpec := k8s.PodSpec(obj)
+	if podSpec == nil {
+		return
+	}
+	for _, container := range k8s.Containers(podSpec) {
+		ref, ok := container["image"].(string)
+		if !ok {
+			continue
+		}
+		image := k8s.ParseImage(ref)
+		for _, override := range overrides {
+			if image.Name != override.name {
+				continue
+			}
+			if override.newName != "" {
+				image.Name = override.newName
+			}
+			if override.newTag != "" {
+				image.Tag = override.newTag
+				image.Digest = ""
+			}
+			if override.digest != "" {
+				image.Digest = override.digest
+				image.Tag = ""
+			}
+			container["image"] = image.String()
+			break
+		}
+	}
+}
+
+// deepCopy returns a copy of v, copying any nested maps and lists.
+func deepCopy(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		ret := make(map[string]any, len(val))
+		for k, item := range val {
+			ret[k] = deepCopy(item)
+		}
+		return ret
+	case []any:
+		ret := make([]any, len(val))
+		for i, item := range val {
+			ret[i] = deepCopy(item)
+		}
+		return ret
+	default:
+		return v
+	}
+}
@@ -27,20 +27,20 @@ func NewKustomize(dir string, cfg config.KustomizeGenerator, refStore *Store) *K
 	}
 }
 
-func (h *Kustomize) Generate(context.Context) (*Result, error) {
+func (h *Kustomize) Generate(ctx context.Context) (*Result, error) {
 	var buf bytes.Buffer
 	kustomizeArgs := []string{
 		"build",
 	}
-	dir, err := h.refStore.GetStringValue(h.dir, h.cfg.Dir)
+	dir, err := h.refStore.GetStringValue(ctx, h.dir, h.cfg.Dir)
 	if err != nil {
 		return nil, err
 	}
-	u, err := h.refStore.GetStringValue(h.dir, h.cfg.URL)
+	u, err := h.refStore.GetStringValue(ctx, h.dir, h.cfg.URL)
 	if err != nil {
 		return nil, err
 	}
-	enableHelm, err := h.refStore.GetBoolValue(h.dir, h.cfg.EnableHelm)
+	enableHelm, err := h.refStore.GetBoolValue(ctx, h.dir, h.cfg.EnableHelm)
 	if err != nil {
 		return nil, err
 	}
@@ -2,10 +2,17 @@ package generator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/chancez/yamlforge/pkg/k8s"
 	"github.com/chancez/yamlforge/pkg/mapmerge"
+	"github.com/chancez/yamlforge/pkg/yamldoc"
 )
 
 var _ Generator = (*Merge)(nil)
@@ -24,14 +31,147 @@ func NewMerge(dir string, cfg config.MergeGenerator, refStore *Store) *Merge {
 	}
 }
 
-func (m *Merge) Generate(_ context.Context) (*Result, error) {
+func (m *Merge) Generate(ctx context.Context) (*Result, error) {
+	if len(m.cfg.Input) != 0 && len(m.cfg.Streams) != 0 {
+		return nil, errors.New("cannot specify both input and streams")
+	}
+	preserve, err := m.refStore.GetBoolValue(ctx, m.dir, m.cfg.Preserve)
+	if err != nil {
+		return nil, fmt.Errorf("error getting preserve: %w", err)
+	}
+	if len(m.cfg.Streams) != 0 {
+		if preserve {
+			return nil, errors.New("preserve is not supported with streams")
+		}
+		return m.mergeStreams(ctx)
+	}
+
 	merged := make(map[string]any)
 	for _, input := range m.cfg.Input {
-		val, err := m.refStore.GetMapValue(m.dir, input)
+		val, err := m.refStore.GetMapValue(ctx, m.dir, input)
 		if err != nil {
 			return nil, fmt.Errorf("error getting value: %w", err)
 		}
 		merged = mapmerge.Merge(merged, val)
 	}
+	if preserve {
+		return m.preserve(ctx, merged)
+	}
 	return &Result{Output: merged}, nil
 }
+
+// preserve returns the YAML of the first input updated to contain merged.
+func (m *Merge) preserve(ctx context.Context, merged map[string]any) (*Result, error) {
+	if len(m.cfg.Input) == 0 {
+		return &Result{Output: merged}, nil
+	}
+	first := m.cfg.Input[0]
+	if first.Value == nil {
+		return nil, errors.New("preserve requires the first input to be YAML, such as a file")
+	}
+	res, err := m.refStore.GetValue(ctx, m.dir, *first.Value)
+	if err != nil {
+		return nil, fmt.Errorf("error getting value: %w", err)
+	}
+	data, ok := yamlText(res)
+	if !ok {
+		return nil, errors.New("preserve requires the first input to be YAML, such as a file")
+	}
+	doc, err := yamldoc.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing input: %w", err)
+	}
+	if err := doc.Update([]any{merged}); err != nil {
+		return nil, fmt.Errorf("error updating input: %w", err)
+	}
+	return &Result{Output: doc.Bytes(), Format: "yaml"}, nil
+}
+
+func (m *Merge) mergeStreams(ctx context.Context) (*Result, error) {
+	onConflict, err := m.refStore.GetStringValue(ctx, m.dir, m.cfg.OnConflict)
+	if err != nil {
+		return nil, fmt.Errorf("error getting onConflict: %w", err)
+	}
+	switch onConflict {
+	case "":
+		onConflict = "ignore"
+	case "ignore", "warn", "error":
+	default:
+		return nil, fmt.Errorf("invalid onConflict %q, must be one of ignore, warn or error", onConflict)
+	}
+
+	// docs holds the output documents in the order they were first seen.
+	// Merged documents are updated in place using the index stored in
+	// positions.
+	var docs []any
+	positions := make(map[k8s.ResourceID]int)
+	for i, input := range m.cfg.Streams {
+		vals, err := m.refStore.GetParsedValues(ctx, m.dir, input)
+		if err != nil {
+			return nil, fmt.Errorf("streams[%d]: error getting value: %w", i, err)
+		}
+		for val, err := range vals {
+			if err != nil {
+				return nil, fmt.Errorf("streams[%d]: error while processing input: %w", i, err)
+			}
+			doc := val.Parsed()
+			if doc == nil {
+				continue
+			}
+			obj, ok := doc.(map[string]any)
+			if !ok {
+				docs = append(docs, doc)
+				continue
+			}
+			id, ok := k8s.GetResourceID(obj)
+			if !ok {
+				docs = append(docs, doc)
+				continue
+			}
+			pos, exists := positions[id]
+			if !exists {
+				positions[id] = len(docs)
+				docs = append(docs, mapmerge.Merge(make(map[string]any), obj))
+				continue
+			}
+
+			existing := docs[pos].(map[string]any)
+			if onConflict != "ignore" {
+				conflicts := mergeConflicts(existing, obj, "")
+				if len(conflicts) != 0 {
+					msg := fmt.Sprintf("streams[%d]: %s has conflicting values for %s", i, id, strings.Join(conflicts, ", "))
+					if onConflict == "error" {
+						return nil, errors.New(msg)
+					}
+					fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
+				}
+			}
+			docs[pos] = mapmerge.Merge(existing, obj)
+		}
+	}
+	return &Result{Output: docs}, nil
+}
+
+// mergeConflicts returns the paths of fields set in both dst and src which
+// would be overwritten by merging src into dst with a different value.
+func mergeConflicts(dst, src map[string]any, prefix string) []string {
+	var conflicts []string
+	for key, srcVal := range src {
+		dstVal, ok := dst[key]
+		if !ok {
+			continue
+		}
+		fieldPath := prefix + "." + key
+		srcMap, srcMapOk := srcVal.(map[string]any)
+		dstMap, dstMapOk := dstVal.(map[string]any)
+		if srcMapOk && dstMapOk {
+			conflicts = append(conflicts, mergeConflicts(dstMap, srcMap, fieldPath)...)
+			continue
+		}
+		if !reflect.DeepEqual(dstVal, srcVal) {
+			conflicts = append(conflicts, fieldPath)
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeConflicts(t *testing.T) {
+	dst := map[string]any{
+		"metadata": map[string]any{"name": "app", "labels": map[string]any{"tier": "web"}},
+		"spec":     map[string]any{"replicas": 1, "paused": false},
+	}
+	src := map[string]any{
+		"metadata": map[string]any{"name": "app", "labels": map[string]any{"tier": "api", "team": "a"}},
+		"spec":     map[string]any{"replicas": 3, "paused": false},
+	}
+	assert.Equal(t, []string{".metadata.labels.tier", ".spec.replicas"}, mergeConflicts(dst, src, ""))
+	assert.Empty(t, mergeConflicts(dst, map[string]any{"status": map[string]any{}}, ""))
+}
+
+func TestMergeStreamsOnConflict(t *testing.T) {
+	store := NewStore(nil)
+	require.NoError(t, store.AddReference("base", &Result{Format: "yaml", Output: []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 1
+`)}))
+	require.NoError(t, store.AddReference("overrides", &Result{Format: "yaml", Output: []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 3
+`)}))
+
+	merge := func(onConflict string) (*Result, error) {
+		return NewMerge("", config.MergeGenerator{
+			Streams:    []config.Value{{Ref: "base"}, {Ref: "overrides"}},
+			OnConflict: config.StringOrValue{String: &onConflict},
+		}, store).Generate(context.Background())
+	}
+
+	expected := []any{map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "app"},
+		"spec":       map[string]any{"replicas": uint64(3)},
+	}}
+	for _, onConflict := range []string{"ignore", "warn"} {
+		res, err := merge(onConflict)
+		require.NoError(t, err, onConflict)
+		assert.Equal(t, expected, res.Output, onConflict)
+	}
+
+	_, err := merge("error")
+	assert.EqualError(t, err, "streams[1]: apps/v1 Deployment app has conflicting values for .spec.replicas")
+
+	_, err = merge("fail")
+	assert.EqualError(t, err, `invalid onConflict "fail", must be one of ignore, warn or error`)
+}
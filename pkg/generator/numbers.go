@@ -0,0 +1,37 @@
+package generator
+
+import "math"
+
+// normalizeNumbers returns a copy of v with integral numbers converted to
+// int64, recursing into maps and lists. Values decoded from JSON, such as the
+// value of a 'value' source, represent every number as a float64, and YAML
+// decodes positive integers as uint64, neither of which languages like CEL,
+// Starlark or CUE treat as integers.
+func normalizeNumbers(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		ret := make(map[string]any, len(val))
+		for k, item := range val {
+			ret[k] = normalizeNumbers(item)
+		}
+		return ret
+	case []any:
+		ret := make([]any, len(val))
+		for i, item := range val {
+			ret[i] = normalizeNumbers(item)
+		}
+		return ret
+	case float64:
+		if val == math.Trunc(val) && val >= math.MinInt64 && val < math.MaxInt64 {
+			return int64(val)
+		}
+		return val
+	case uint64:
+		if val <= math.MaxInt64 {
+			return int64(val)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chancez/yamlforge/pkg/config"
+
+	"github.com/ohler55/ojg/jp"
+)
+
+// errPathNotFound is returned when a path or select expression doesn't match
+// anything in a value.
+var errPathNotFound = errors.New("not found")
+
+// extractValue parses res and returns the sub-value selected by the path and
+// select options of ref. Outputs with multiple documents are treated as a
+// list of documents.
+func extractValue(res *Result, ref config.Value) (*Result, error) {
+	format := ref.Format
+	if format == "" {
+		format = "yaml"
+	}
+	var val any
+	if res != nil && res.Output != nil {
+		var err error
+		val, err = parseReference(&Result{Output: res.Output, Format: resultFormat(res, format)})
+		if err != nil {
+			return nil, fmt.Errorf("error parsing value: %w", err)
+		}
+	}
+
+	missing := func(kind, expr string) (*Result, error) {
+		if ref.IgnoreMissing {
+			return &Result{Output: ref.Default}, nil
+		}
+		return nil, fmt.Errorf("%s %q: %w", kind, expr, errPathNotFound)
+	}
+
+	if ref.Path != "" {
+		var err error
+		val, err = lookupPath(val, ref.Path)
+		if errors.Is(err, errPathNotFound) {
+			return missing("path", ref.Path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", ref.Path, err)
+		}
+	}
+
+	if ref.Select != "" {
+		expr, err := jp.ParseString(ref.Select)
+		if err != nil {
+			return nil, fmt.Errorf("invalid select %q: %w", ref.Select, err)
+		}
+		matches := expr.Get(val)
+		if !isSingularQuery(expr) {
+			if matches == nil {
+				matches = []any{}
+			}
+			return &Result{Output: matches}, nil
+		}
+		if len(matches) == 0 {
+			return missing("select", ref.Select)
+		}
+		val = matches[0]
+	}
+	return &Result{Output: val}, nil
+}
+
+// resultFormat returns the format of res, or defaultFormat if res has none.
+func resultFormat(res *Result, defaultFormat string) string {
+	if res.Format != "" {
+		return res.Format
+	}
+	return defaultFormat
+}
+
+// isSingularQuery reports if expr can match at most one value, in which case
+// the match is returned directly rather than as a list of matches.
+func isSingularQuery(expr jp.Expr) bool {
+	for _, frag := range expr {
+		switch frag.(type) {
+		case jp.Root, jp.At, jp.Bracket, jp.Child, jp.Nth:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// lookupPath returns the value at p within val. p is either a JSON Pointer
+// (RFC 6901) such as /spec/ports/0, or a dotted path such as spec.ports.0.
+func lookupPath(val any, p string) (any, error) {
+	var tokens []string
+	if strings.HasPrefix(p, "/") {
+		for _, token := range strings.Split(p[1:], "/") {
+			token = strings.ReplaceAll(token, "~1", "/")
+			token = strings.ReplaceAll(token, "~0", "~")
+			tokens = append(tokens, token)
+		}
+	} else {
+		tokens = strings.Split(p, ".")
+	}
+
+	for _, token := range tokens {
+		switch v := val.(type) {
+		case map[string]any:
+			next, ok := v[token]
+			if !ok {
+				return nil, errPathNotFound
+			}
+			val = next
+		case []any:
+			idx, err := strconv.Atoi(token)
+			if err != nil {
+				return nil, fmt.Errorf("invalid array index %q", token)
+			}
+			if idx < 0 || idx >= len(v) {
+				return nil, errPathNotFound
+			}
+			val = v[idx]
+		default:
+			return nil, errPathNotFound
+		}
+	}
+	return val, nil
+}
@@ -78,7 +78,7 @@ func (pipeline *Pipeline) Generate(ctx context.Context) (*Result, error) {
 }
 
 func (pipeline *Pipeline) executeImport(ctx context.Context) (*Result, error) {
-	data, err := pipeline.refStore.GetValueBytes(pipeline.dir, *pipeline.cfg.Import)
+	data, err := pipeline.refStore.GetValueBytes(ctx, pipeline.dir, *pipeline.cfg.Import)
 	if err != nil {
 		return nil, fmt.Errorf("error getting value to import: %w", err)
 	}
@@ -93,7 +93,7 @@ func (pipeline *Pipeline) executeImport(ctx context.Context) (*Result, error) {
 		if pipelineVar.Name == "" {
 			return nil, fmt.Errorf("vars[%d]: pipeline variable name cannot be empty", i)
 		}
-		ref, err := pipeline.refStore.GetValue(pipeline.dir, pipelineVar.Value)
+		ref, err := pipeline.refStore.GetValue(ctx, pipeline.dir, pipelineVar.Value)
 		if err != nil {
 			return nil, fmt.Errorf("variable %q: error getting pipeline variable reference: %w", pipelineVar.Name, err)
 		}
@@ -115,13 +115,13 @@ func (pipeline *Pipeline) executeImport(ctx context.Context) (*Result, error) {
 			subPipelineDir = filepath.Dir(pipeline.cfg.Import.File)
 		}
 	}
-	newStore := NewStore(pipelineVars)
+	newStore := pipeline.refStore.newChild(pipelineVars)
 	subPipeline := NewPipeline(subPipelineDir, subPipelineCfg.PipelineGenerator, newStore, pipeline.debug)
 	return subPipeline.Generate(ctx)
 }
 
 func (pipeline *Pipeline) executeInclude(ctx context.Context) (*Result, error) {
-	data, err := pipeline.refStore.GetValueBytes(pipeline.dir, *pipeline.cfg.Include)
+	data, err := pipeline.refStore.GetValueBytes(ctx, pipeline.dir, *pipeline.cfg.Include)
 	if err != nil {
 		return nil, fmt.Errorf("error getting value to import: %w", err)
 	}
@@ -137,21 +137,26 @@ func (pipeline *Pipeline) executeInclude(ctx context.Context) (*Result, error) {
 }
 
 func (pipeline *Pipeline) executeGenerator(ctx context.Context, generatorCfg config.Generator) (*Result, error) {
-	kind, gen, err := pipeline.getGenerator(generatorCfg)
+	// Stages which enable interpolation get a store which interpolates the
+	// strings of their configuration.
+	refStore := pipeline.refStore.withInterpolation(generatorCfg.Interpolate)
+	kind, gen, err := pipeline.getGenerator(generatorCfg, refStore)
 	if err != nil {
 		return nil, fmt.Errorf("error getting generator: %w", err)
 	}
 	result, err := gen.Generate(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error executing %q generator: %w", kind, err)
+		return nil, pipeline.refStore.RedactError(fmt.Errorf("error executing %q generator: %w", kind, err))
 	}
 	if pipeline.debug {
-		fmt.Printf("[DEBUG (generator: %q) - name: %q format: %q]:\n%s\n\n", kind, generatorCfg.Name, result.Format, result.Output)
+		fmt.Print(pipeline.refStore.Redact(fmt.Sprintf("[DEBUG (generator: %q) - name: %q format: %q]:\n%s\n\n", kind, generatorCfg.Name, result.Format, result.Output)))
 	}
 	return result, nil
 }
 
-func (pipeline *Pipeline) getGenerator(generatorCfg config.Generator) (string, Generator, error) {
+// getGenerator returns the generator configured by generatorCfg, using
+// refStore to get its values.
+func (pipeline *Pipeline) getGenerator(generatorCfg config.Generator, refStore *Store) (string, Generator, error) {
 	var (
 		kind string
 		gen  Generator
@@ -159,43 +164,100 @@ func (pipeline *Pipeline) getGenerator(generatorCfg config.Generator) (string, G
 	switch {
 	case generatorCfg.File != nil:
 		kind = "file"
-		gen = NewFile(pipeline.dir, *generatorCfg.File)
+		gen = NewFile(pipeline.dir, *generatorCfg.File, refStore)
 	case generatorCfg.Value != nil:
 		kind = "value"
-		gen = NewValue(pipeline.dir, *generatorCfg.Value, pipeline.refStore)
+		gen = NewValue(pipeline.dir, *generatorCfg.Value, refStore)
 	case generatorCfg.Exec != nil:
 		kind = "exec"
-		gen = NewExec(pipeline.dir, *generatorCfg.Exec, pipeline.refStore)
+		gen = NewExec(pipeline.dir, *generatorCfg.Exec, refStore)
 	case generatorCfg.Helm != nil:
 		kind = "helm"
-		gen = NewHelm(pipeline.dir, *generatorCfg.Helm, pipeline.refStore)
+		gen = NewHelm(pipeline.dir, *generatorCfg.Helm, refStore)
 	case generatorCfg.Kustomize != nil:
 		kind = "kustomize"
-		gen = NewKustomize(pipeline.dir, *generatorCfg.Kustomize, pipeline.refStore)
+		gen = NewKustomize(pipeline.dir, *generatorCfg.Kustomize, refStore)
 	case generatorCfg.Merge != nil:
 		kind = "merge"
-		gen = NewMerge(pipeline.dir, *generatorCfg.Merge, pipeline.refStore)
+		gen = NewMerge(pipeline.dir, *generatorCfg.Merge, refStore)
 	case generatorCfg.GoTemplate != nil:
 		kind = "gotemplate"
-		gen = NewGoTemplate(pipeline.dir, *generatorCfg.GoTemplate, pipeline.refStore)
+		gen = NewGoTemplate(pipeline.dir, *generatorCfg.GoTemplate, refStore)
 	case generatorCfg.Pipeline != nil:
 		kind = "pipeline"
-		gen = NewPipeline(pipeline.dir, *generatorCfg.Pipeline, pipeline.refStore, pipeline.debug)
+		gen = NewPipeline(pipeline.dir, *generatorCfg.Pipeline, refStore, pipeline.debug)
 	case generatorCfg.JQ != nil:
 		kind = "jq"
-		gen = NewJQ(pipeline.dir, *generatorCfg.JQ, pipeline.refStore)
+		gen = NewJQ(pipeline.dir, *generatorCfg.JQ, refStore)
 	case generatorCfg.CEL != nil:
 		kind = "cel"
-		gen = NewCEL(pipeline.dir, *generatorCfg.CEL, pipeline.refStore)
+		gen = NewCEL(pipeline.dir, *generatorCfg.CEL, refStore)
 	case generatorCfg.JSONPatch != nil:
 		kind = "jsonpatch"
-		gen = NewJSONPatch(pipeline.dir, *generatorCfg.JSONPatch, pipeline.refStore)
+		gen = NewJSONPatch(pipeline.dir, *generatorCfg.JSONPatch, refStore)
 	case generatorCfg.YAML != nil:
 		kind = "yaml"
-		gen = NewYAML(pipeline.dir, *generatorCfg.YAML, pipeline.refStore)
+		gen = NewYAML(pipeline.dir, *generatorCfg.YAML, refStore)
 	case generatorCfg.JSON != nil:
 		kind = "json"
-		gen = NewJSON(pipeline.dir, *generatorCfg.JSON, pipeline.refStore)
+		gen = NewJSON(pipeline.dir, *generatorCfg.JSON, refStore)
+	case generatorCfg.Kubernetes != nil:
+		kind = "k8s"
+		gen = NewKubernetes(pipeline.dir, *generatorCfg.Kubernetes, refStore)
+	case generatorCfg.ConfigMap != nil:
+		kind = "configMap"
+		gen = NewConfigMap(pipeline.dir, *generatorCfg.ConfigMap, refStore)
+	case generatorCfg.Secret != nil:
+		kind = "secret"
+		gen = NewSecret(pipeline.dir, *generatorCfg.Secret, refStore)
+	case generatorCfg.Sort != nil:
+		kind = "sort"
+		gen = NewSort(pipeline.dir, *generatorCfg.Sort, refStore)
+	case generatorCfg.Validate != nil:
+		kind = "validate"
+		gen = NewValidate(pipeline.dir, *generatorCfg.Validate, refStore)
+	case generatorCfg.JSONSchema != nil:
+		kind = "jsonschema"
+		gen = NewJSONSchema(pipeline.dir, *generatorCfg.JSONSchema, refStore)
+	case generatorCfg.Assert != nil:
+		kind = "assert"
+		gen = NewAssert(pipeline.dir, *generatorCfg.Assert, refStore)
+	case generatorCfg.Starlark != nil:
+		kind = "starlark"
+		gen = NewStarlark(pipeline.dir, *generatorCfg.Starlark, refStore)
+	case generatorCfg.Jsonnet != nil:
+		kind = "jsonnet"
+		gen = NewJsonnet(pipeline.dir, *generatorCfg.Jsonnet, refStore)
+	case generatorCfg.CUE != nil:
+		kind = "cue"
+		gen = NewCUE(pipeline.dir, *generatorCfg.CUE, refStore)
+	case generatorCfg.TOML != nil:
+		kind = "toml"
+		gen = NewEncode(pipeline.dir, formatEncodeConfig("toml", *generatorCfg.TOML), refStore)
+	case generatorCfg.HCL != nil:
+		kind = "hcl"
+		gen = NewEncode(pipeline.dir, formatEncodeConfig("hcl", *generatorCfg.HCL), refStore)
+	case generatorCfg.INI != nil:
+		kind = "ini"
+		gen = NewEncode(pipeline.dir, formatEncodeConfig("ini", *generatorCfg.INI), refStore)
+	case generatorCfg.Dotenv != nil:
+		kind = "dotenv"
+		gen = NewEncode(pipeline.dir, formatEncodeConfig("dotenv", *generatorCfg.Dotenv), refStore)
+	case generatorCfg.Properties != nil:
+		kind = "properties"
+		gen = NewEncode(pipeline.dir, formatEncodeConfig("properties", *generatorCfg.Properties), refStore)
+	case generatorCfg.XML != nil:
+		kind = "xml"
+		gen = NewEncode(pipeline.dir, formatEncodeConfig("xml", *generatorCfg.XML), refStore)
+	case generatorCfg.CSV != nil:
+		kind = "csv"
+		gen = NewEncode(pipeline.dir, formatEncodeConfig("csv", *generatorCfg.CSV), refStore)
+	case generatorCfg.Encode != nil:
+		kind = "encode"
+		gen = NewEncode(pipeline.dir, *generatorCfg.Encode, refStore)
+	case generatorCfg.HTTP != nil:
+		kind = "http"
+		gen = NewHTTP(pipeline.dir, *generatorCfg.HTTP, refStore)
 	default:
 		return "", nil, fmt.Errorf("generator not configured")
 	}
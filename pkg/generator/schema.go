@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var schemaMessagePrinter = message.NewPrinter(language.English)
+
+// schemaViolation is a single reason a value failed JSON schema validation.
+type schemaViolation struct {
+	// pointer is a JSON pointer to the invalid field.
+	pointer string
+	message string
+}
+
+func (v schemaViolation) String() string {
+	pointer := v.pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return fmt.Sprintf("%s: %s", pointer, v.message)
+}
+
+// compileSchema compiles a JSON schema which has already been parsed into
+// doc. url identifies the schema in error messages.
+func compileSchema(url string, doc any) (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	err := c.AddResource(url, doc)
+	if err != nil {
+		return nil, err
+	}
+	return c.Compile(url)
+}
+
+// validateSchema validates val against sch, returning the violations found.
+func validateSchema(sch *jsonschema.Schema, val any) ([]schemaViolation, error) {
+	err := sch.Validate(val)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+	var violations []schemaViolation
+	var collect func(e *jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			violations = append(violations, schemaViolation{
+				pointer: jsonPointer(e.InstanceLocation),
+				message: e.ErrorKind.LocalizedString(schemaMessagePrinter),
+			})
+			return
+		}
+		for _, cause := range e.Causes {
+			collect(cause)
+		}
+	}
+	collect(validationErr)
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].pointer < violations[j].pointer
+	})
+	return violations, nil
+}
+
+// jsonPointer returns the RFC 6901 JSON pointer for tokens.
+func jsonPointer(tokens []string) string {
+	var sb strings.Builder
+	replacer := strings.NewReplacer("~", "~0", "/", "~1")
+	for _, tok := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(replacer.Replace(tok))
+	}
+	return sb.String()
+}
@@ -0,0 +1,285 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/chancez/yamlforge/pkg/config"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// minSecretLength is the length of the shortest secret which is redacted.
+// Shorter values, such as booleans or small numbers, would redact unrelated
+// output.
+const minSecretLength = 4
+
+const redacted = "<redacted>"
+
+// secrets records the values of sensitive values, so they can be redacted
+// from debug output and error messages.
+type secrets struct {
+	mu     sync.RWMutex
+	values map[string]struct{}
+}
+
+func newSecrets() *secrets {
+	return &secrets{values: make(map[string]struct{})}
+}
+
+// add records each string and number within val as a secret.
+func (s *secrets) add(val any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var walk func(val any)
+	walk = func(val any) {
+		switch v := val.(type) {
+		case map[string]any:
+			for _, item := range v {
+				walk(item)
+			}
+		case []any:
+			for _, item := range v {
+				walk(item)
+			}
+		case []byte:
+			walk(string(v))
+		case string:
+			trimmed := strings.TrimSpace(v)
+			if len(trimmed) < minSecretLength {
+				return
+			}
+			s.values[trimmed] = struct{}{}
+			// Secrets are often written as quoted strings, for example when
+			// they contain new lines, or base64 encoded, such as in the data of
+			// a Secret.
+			if quoted := strconv.Quote(trimmed); quoted[1:len(quoted)-1] != trimmed {
+				s.values[quoted[1:len(quoted)-1]] = struct{}{}
+			}
+			s.values[base64.StdEncoding.EncodeToString([]byte(trimmed))] = struct{}{}
+			s.values[base64.StdEncoding.EncodeToString([]byte(v))] = struct{}{}
+		case nil, bool:
+		default:
+			walk(fmt.Sprint(v))
+		}
+	}
+	walk(val)
+}
+
+// redact replaces each secret within str.
+func (s *secrets) redact(str string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.values) == 0 {
+		return str
+	}
+	values := make([]string, 0, len(s.values))
+	for v := range s.values {
+		values = append(values, v)
+	}
+	// Replace longer secrets first, in case they contain shorter ones.
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+	for _, v := range values {
+		str = strings.ReplaceAll(str, v, redacted)
+	}
+	return str
+}
+
+// redactError returns err with secrets redacted from its message.
+func (s *secrets) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := s.redact(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{err: err, msg: msg}
+}
+
+// redactedError is an error with secrets redacted from its message, which
+// still wraps the original error.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// isSensitive reports if the value retrieved for ref contains secrets.
+func isSensitive(ref config.Value) bool {
+	return ref.Sensitive || ref.SOPS != nil || ref.Age != nil
+}
+
+// getSOPSValue decrypts the file of ref.SOPS using the sops command.
+func (store *Store) getSOPSValue(ctx context.Context, dir string, ref config.Value) (*Result, error) {
+	file, err := store.GetStringValue(ctx, dir, ref.SOPS.File)
+	if err != nil {
+		return nil, fmt.Errorf("error getting file: %w", err)
+	}
+	if file == "" {
+		return nil, errors.New("sops: file is required")
+	}
+	format, err := store.GetStringValue(ctx, dir, ref.SOPS.Format)
+	if err != nil {
+		return nil, fmt.Errorf("error getting format: %w", err)
+	}
+	if format == "" {
+		format = formatFromFileName(file)
+	}
+	p := filepath.Join(dir, file)
+	if _, err := os.Stat(p); err != nil {
+		if errors.Is(err, os.ErrNotExist) && ref.IgnoreMissing {
+			return &Result{Output: ref.Default}, nil
+		}
+		return nil, fmt.Errorf("error opening file %q", file)
+	}
+
+	args := []string{"--decrypt"}
+	if format != "" {
+		args = append(args, "--input-type", format, "--output-type", format)
+	}
+	args = append(args, p)
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sops", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("error decrypting %q: %w: %s", file, err, msg)
+		}
+		return nil, fmt.Errorf("error decrypting %q: %w", file, err)
+	}
+	return decryptedResult(stdout.Bytes(), format)
+}
+
+// getAgeValue decrypts the file of ref.Age.
+func (store *Store) getAgeValue(ctx context.Context, dir string, ref config.Value) (*Result, error) {
+	file, err := store.GetStringValue(ctx, dir, ref.Age.File)
+	if err != nil {
+		return nil, fmt.Errorf("error getting file: %w", err)
+	}
+	if file == "" {
+		return nil, errors.New("age: file is required")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && ref.IgnoreMissing {
+			return &Result{Output: ref.Default}, nil
+		}
+		return nil, fmt.Errorf("error opening file %q", file)
+	}
+	identities, err := store.ageIdentities(ctx, dir, ref.Age.Identities)
+	if err != nil {
+		return nil, err
+	}
+
+	var src io.Reader = bytes.NewReader(data)
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(trimmed))
+	}
+	r, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting %q: %w", file, err)
+	}
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(r); err != nil {
+		return nil, fmt.Errorf("error decrypting %q: %w", file, err)
+	}
+	format := formatFromFileName(strings.TrimSuffix(file, ".age"))
+	if format == "" {
+		format = ref.Format
+	}
+	return decryptedResult(out.Bytes(), format)
+}
+
+// ageIdentities returns the identities in each value, or the default
+// identities used by sops if there are none.
+func (store *Store) ageIdentities(ctx context.Context, dir string, vals []config.Value) ([]age.Identity, error) {
+	var identities []age.Identity
+	for i, val := range vals {
+		data, err := store.GetValueBytes(ctx, dir, val)
+		if err != nil {
+			return nil, fmt.Errorf("identities[%d]: error getting value: %w", i, err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if !strings.HasPrefix(line, "#") {
+				store.secrets.add(line)
+			}
+		}
+		ids, err := age.ParseIdentities(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("identities[%d]: error parsing identities: %w", i, err)
+		}
+		identities = append(identities, ids...)
+	}
+	if len(vals) != 0 {
+		return identities, nil
+	}
+
+	if key := os.Getenv("SOPS_AGE_KEY"); key != "" {
+		ids, err := age.ParseIdentities(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing identities from SOPS_AGE_KEY: %w", err)
+		}
+		identities = append(identities, ids...)
+	}
+	keyFile := os.Getenv("SOPS_AGE_KEY_FILE")
+	if keyFile == "" {
+		configDir, err := os.UserConfigDir()
+		if err == nil {
+			keyFile = filepath.Join(configDir, "sops", "age", "keys.txt")
+		}
+	}
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("error reading identities: %w", err)
+		}
+		if err == nil {
+			ids, err := age.ParseIdentities(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing identities from %q: %w", keyFile, err)
+			}
+			identities = append(identities, ids...)
+		}
+	}
+	if len(identities) == 0 {
+		return nil, errors.New("no age identities found, set identities or the SOPS_AGE_KEY_FILE environment variable")
+	}
+	return identities, nil
+}
+
+// decryptedResult returns decrypted data parsed using format, or the raw data
+// if the format is unknown.
+func decryptedResult(data []byte, format string) (*Result, error) {
+	if format == "" {
+		return &Result{Output: data}, nil
+	}
+	val, err := parseReference(&Result{Output: data, Format: format})
+	if err != nil {
+		// The error may include the decrypted data.
+		return nil, fmt.Errorf("error parsing decrypted data as %s", format)
+	}
+	return &Result{Output: val}, nil
+}
@@ -0,0 +1,180 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encryptAge(t *testing.T, recipient age.Recipient, data string, armored bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var dst io.Writer = &buf
+	var armorWriter io.WriteCloser
+	if armored {
+		armorWriter = armor.NewWriter(&buf)
+		dst = armorWriter
+	}
+	w, err := age.Encrypt(dst, recipient)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	if armorWriter != nil {
+		require.NoError(t, armorWriter.Close())
+	}
+	return buf.Bytes()
+}
+
+func TestAge(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	secretYAML := "database:\n  password: hunter22\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secrets.yaml.age"), encryptAge(t, identity.Recipient(), secretYAML, false), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token.age"), encryptAge(t, identity.Recipient(), "s3cr3t-token\n", true), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keys.txt"), []byte("# test key\n"+identity.String()+"\n"), 0600))
+
+	store := NewStore(nil)
+	identities := []config.Value{{File: "keys.txt"}}
+
+	res, err := store.GetValue(context.Background(), dir, config.Value{Age: &config.AgeSource{
+		File:       config.StringOrValue{String: strPtr("secrets.yaml.age")},
+		Identities: identities,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"database": map[string]any{"password": "hunter22"}}, res.Output)
+
+	res, err = store.GetValue(context.Background(), dir, config.Value{Age: &config.AgeSource{
+		File:       config.StringOrValue{String: strPtr("token.age")},
+		Identities: identities,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t-token\n", string(res.Output.([]byte)))
+
+	// Decrypted values and identities are redacted.
+	assert.Equal(t, "password: <redacted>, token: <redacted>", store.Redact("password: hunter22, token: s3cr3t-token"))
+	assert.Equal(t, "key <redacted>", store.Redact("key "+identity.String()))
+	assert.Equal(t, "DB_PASSWORD: <redacted>", store.Redact("DB_PASSWORD: aHVudGVyMjI="))
+	err = store.RedactError(os.ErrNotExist)
+	assert.Same(t, os.ErrNotExist, err)
+	err = store.RedactError(errors.Join(os.ErrNotExist, errors.New("invalid value hunter22")))
+	assert.EqualError(t, err, "file does not exist\ninvalid value <redacted>")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	otherKey := any(other.String())
+	_, err = store.GetValue(context.Background(), dir, config.Value{Age: &config.AgeSource{
+		File:       config.StringOrValue{String: strPtr("token.age")},
+		Identities: []config.Value{{Value: &config.AnyOrValue{Any: &otherKey}}},
+	}})
+	assert.ErrorContains(t, err, `error decrypting "token.age": no identity matched any of the recipients`)
+
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join(dir, "keys.txt"))
+	res, err = store.GetValue(context.Background(), dir, config.Value{Age: &config.AgeSource{
+		File: config.StringOrValue{String: strPtr("secrets.yaml.age")},
+	}, Path: "/database/password"})
+	require.NoError(t, err)
+	assert.Equal(t, "hunter22", res.Output)
+}
+
+func TestSensitive(t *testing.T) {
+	t.Setenv("API_TOKEN", "token-value")
+	t.Setenv("REGION", "us-west-2")
+	store := NewStore(nil)
+	_, err := store.GetValue(context.Background(), "", config.Value{Env: "API_TOKEN", Sensitive: true})
+	require.NoError(t, err)
+	_, err = store.GetValue(context.Background(), "", config.Value{Env: "REGION"})
+	require.NoError(t, err)
+	assert.Equal(t, "<redacted> us-west-2", store.Redact("token-value us-west-2"))
+}
+
+func TestSOPSAge(t *testing.T) {
+	if _, err := exec.LookPath("sops"); err != nil {
+		t.Skip("sops is not installed")
+	}
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keys.txt")
+	require.NoError(t, os.WriteFile(keyFile, []byte(identity.String()+"\n"), 0600))
+	t.Setenv("SOPS_AGE_KEY_FILE", keyFile)
+	t.Setenv("SOPS_AGE_KEY", "")
+
+	plain := filepath.Join(dir, "secrets.yaml")
+	require.NoError(t, os.WriteFile(plain, []byte("apiKey: abcdef\nregion: us-west-2\n"), 0600))
+	encrypted, err := exec.Command("sops", "--encrypt", "--age", identity.Recipient().String(), plain).Output()
+	require.NoError(t, err)
+	require.NotContains(t, string(encrypted), "abcdef")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secrets.enc.yaml"), encrypted, 0600))
+
+	store := NewStore(nil)
+	res, err := store.GetValue(context.Background(), dir, config.Value{SOPS: &config.SOPSSource{
+		File: config.StringOrValue{String: strPtr("secrets.enc.yaml")},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"apiKey": "abcdef", "region": "us-west-2"}, res.Output)
+	assert.Equal(t, "key: <redacted>", store.Redact("key: abcdef"))
+
+	// Files can't be decrypted without the key.
+	require.NoError(t, os.Remove(keyFile))
+	_, err = store.GetValue(context.Background(), dir, config.Value{SOPS: &config.SOPSSource{
+		File: config.StringOrValue{String: strPtr("secrets.enc.yaml")},
+	}})
+	assert.ErrorContains(t, err, `error decrypting "secrets.enc.yaml"`)
+}
+
+func TestSOPS(t *testing.T) {
+	// Use a fake sops command which prints the file, so the real command isn't
+	// required.
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nfor last; do true; done\ncat \"$last\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "sops"), []byte(script), 0700))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secrets.enc.yaml"), []byte("apiKey: abcdef\n"), 0600))
+	store := NewStore(nil)
+	res, err := store.GetValue(context.Background(), dir, config.Value{SOPS: &config.SOPSSource{
+		File: config.StringOrValue{String: strPtr("secrets.enc.yaml")},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"apiKey": "abcdef"}, res.Output)
+	assert.Equal(t, "key: <redacted>", store.Redact("key: abcdef"))
+
+	res, err = store.GetValue(context.Background(), dir, config.Value{SOPS: &config.SOPSSource{
+		File: config.StringOrValue{String: strPtr("missing.yaml")},
+	}, IgnoreMissing: true, Default: map[string]any{}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{}, res.Output)
+
+	// Errors containing sensitive values are redacted.
+	err = store.AddReference("secrets", &Result{Output: []byte("apiKey: abcdef\n"), Format: "yaml"})
+	require.NoError(t, err)
+	pipeline := NewPipeline(dir, config.PipelineGenerator{
+		Pipeline: []config.Generator{
+			{
+				Name: "fail",
+				GoTemplate: &config.GoTemplateGenerator{
+					Template: config.StringOrValue{String: strPtr(`{{ fail (printf "invalid key %s" (ref "secrets").apiKey) }}`)},
+				},
+			},
+		},
+	}, store, false)
+	_, err = pipeline.Generate(context.Background())
+	assert.ErrorContains(t, err, "invalid key <redacted>")
+	assert.NotContains(t, err.Error(), "abcdef")
+}
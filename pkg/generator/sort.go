@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/chancez/yamlforge/pkg/k8s"
+)
+
+var _ Generator = (*Sort)(nil)
+
+type Sort struct {
+	dir      string
+	cfg      config.SortGenerator
+	refStore *Store
+}
+
+func NewSort(dir string, cfg config.SortGenerator, refStore *Store) *Sort {
+	return &Sort{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+func (s *Sort) Generate(ctx context.Context) (*Result, error) {
+	order, err := s.refStore.GetStringValueList(ctx, s.dir, s.cfg.Order)
+	if err != nil {
+		return nil, fmt.Errorf("error getting order: %w", err)
+	}
+	if len(order) == 0 {
+		order = k8s.InstallOrder
+	}
+
+	var docs []any
+	for _, input := range s.cfg.Input {
+		vals, err := s.refStore.GetParsedValues(ctx, s.dir, input)
+		if err != nil {
+			return nil, fmt.Errorf("error getting value: %w", err)
+		}
+		for val, err := range vals {
+			if err != nil {
+				return nil, fmt.Errorf("error while processing input: %w", err)
+			}
+			if val.Parsed() == nil {
+				continue
+			}
+			docs = append(docs, val.Parsed())
+		}
+	}
+	k8s.SortByKind(docs, order)
+	return &Result{Output: docs}, nil
+}
+
+// SortResult sorts the Kubernetes resources in res into the order Helm
+// installs resources in, returning them as a stream of documents in the format
+// of res, or YAML if it has no format.
+func SortResult(res *Result) (*Result, error) {
+	format := res.Format
+	if format == "" {
+		format = "yaml"
+	}
+	vals, err := parseResult(res, format)
+	if err != nil {
+		return nil, err
+	}
+	var docs []any
+	for val, err := range vals {
+		if err != nil {
+			return nil, fmt.Errorf("error while processing result: %w", err)
+		}
+		if val.Parsed() == nil {
+			continue
+		}
+		docs = append(docs, val.Parsed())
+	}
+	k8s.SortByKind(docs, k8s.InstallOrder)
+
+	out, err := encodeDocuments(format, docs)
+	if err != nil {
+		return nil, fmt.Errorf("error writing %s: %w", format, err)
+	}
+	return &Result{Output: out, Format: format}, nil
+}
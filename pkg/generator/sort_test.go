@@ -0,0 +1,27 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortResult(t *testing.T) {
+	res, err := SortResult(&Result{
+		Output: []byte("kind: Deployment\nmetadata:\n  name: app\n---\nkind: Namespace\nmetadata:\n  name: ns\n"),
+		Format: "yaml",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "yaml", res.Format)
+	assert.Equal(t, "kind: Namespace\nmetadata:\n    name: ns\n---\nkind: Deployment\nmetadata:\n    name: app\n", string(res.Output.([]byte)))
+
+	// The format of the result is kept.
+	res, err = SortResult(&Result{
+		Output: []byte(`{"kind":"Deployment","metadata":{"name":"app"}}` + "\n" + `{"kind":"Namespace","metadata":{"name":"ns"}}` + "\n"),
+		Format: "json",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "json", res.Format)
+	assert.Equal(t, `{"kind":"Namespace","metadata":{"name":"ns"}}`+"\n"+`{"kind":"Deployment","metadata":{"name":"app"}}`+"\n", string(res.Output.([]byte)))
+}
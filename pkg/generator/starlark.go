@@ -0,0 +1,313 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkjson"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+var _ Generator = (*Starlark)(nil)
+
+type Starlark struct {
+	dir      string
+	cfg      config.StarlarkGenerator
+	refStore *Store
+}
+
+func NewStarlark(dir string, cfg config.StarlarkGenerator, refStore *Store) *Starlark {
+	return &Starlark{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+var starlarkFileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+	Recursion:       true,
+}
+
+// defaultStarlarkMaxSteps is the default maximum number of steps a script can
+// execute, since while loops and recursion allow scripts which never finish.
+const defaultStarlarkMaxSteps = 100_000_000
+
+func (s *Starlark) Generate(ctx context.Context) (*Result, error) {
+	script, err := s.refStore.GetRawStringValue(ctx, s.dir, s.cfg.Script)
+	if err != nil {
+		return nil, fmt.Errorf("error getting script: %w", err)
+	}
+	filename := "script.star"
+	if s.cfg.Script.Value != nil && s.cfg.Script.Value.File != "" {
+		filename = filepath.Join(s.dir, s.cfg.Script.Value.File)
+	}
+
+	vars, err := toStarlark(s.refStore.vars)
+	if err != nil {
+		return nil, fmt.Errorf("error converting vars: %w", err)
+	}
+	refs, err := s.refStore.parsedReferences()
+	if err != nil {
+		return nil, fmt.Errorf("error getting refs: %w", err)
+	}
+	starlarkRefs, err := toStarlark(refs)
+	if err != nil {
+		return nil, fmt.Errorf("error converting refs: %w", err)
+	}
+
+	var emitted []any
+	emit := starlark.NewBuiltin("emit", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if len(kwargs) != 0 {
+			return nil, fmt.Errorf("%s: unexpected keyword arguments", fn.Name())
+		}
+		for _, arg := range args {
+			doc, err := fromStarlark(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+			}
+			emitted = append(emitted, doc)
+		}
+		return starlark.None, nil
+	})
+
+	predeclared := starlark.StringDict{
+		"vars":   vars,
+		"refs":   starlarkRefs,
+		"emit":   emit,
+		"struct": starlark.NewBuiltin("struct", starlarkstruct.Make),
+		"json":   starlarkjson.Module,
+		"yaml":   starlarkYAMLModule,
+	}
+	thread := &starlark.Thread{
+		Name: "yamlforge",
+		Load: func(*starlark.Thread, string) (starlark.StringDict, error) {
+			return nil, errors.New("load is not supported")
+		},
+		Print: func(_ *starlark.Thread, msg string) {
+			fmt.Fprintln(os.Stderr, msg)
+		},
+	}
+	maxSteps := s.cfg.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultStarlarkMaxSteps
+	}
+	thread.SetMaxExecutionSteps(uint64(maxSteps))
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+		case <-done:
+		}
+	}()
+
+	globals, err := starlark.ExecFileOptions(starlarkFileOptions, thread, filename, script, predeclared)
+	if err != nil {
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			return nil, fmt.Errorf("error executing script: %s", evalErr.Backtrace())
+		}
+		return nil, fmt.Errorf("error executing script: %w", err)
+	}
+
+	if emitted == nil {
+		output, ok := globals["output"]
+		if !ok {
+			return nil, errors.New("script did not emit any documents or set 'output'")
+		}
+		out, err := fromStarlark(output)
+		if err != nil {
+			return nil, fmt.Errorf("error converting output: %w", err)
+		}
+		return &Result{Output: out}, nil
+	}
+	if len(emitted) == 1 {
+		return &Result{Output: emitted[0]}, nil
+	}
+	return &Result{Output: emitted}, nil
+}
+
+var starlarkYAMLModule = &starlarkstruct.Module{
+	Name: "yaml",
+	Members: starlark.StringDict{
+		// encode returns the YAML encoding of a value.
+		"encode": starlark.NewBuiltin("yaml.encode", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var val starlark.Value
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &val); err != nil {
+				return nil, err
+			}
+			v, err := fromStarlark(val)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+			}
+			data, err := config.EncodeYAML(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+			}
+			return starlark.String(data), nil
+		}),
+		// decode parses a single YAML document.
+		"decode": starlark.NewBuiltin("yaml.decode", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var s string
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &s); err != nil {
+				return nil, err
+			}
+			var v any
+			if err := config.DecodeYAML([]byte(s), &v); err != nil {
+				return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+			}
+			return toStarlark(v)
+		}),
+		// decode_all parses a stream of YAML documents into a list.
+		"decode_all": starlark.NewBuiltin("yaml.decode_all", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var s string
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &s); err != nil {
+				return nil, err
+			}
+			vals, err := parseResult(&Result{Output: s, Format: "yaml"}, "yaml")
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+			}
+			var docs []any
+			for val, err := range vals {
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+				}
+				docs = append(docs, val.Parsed())
+			}
+			return toStarlark(docs)
+		}),
+	},
+}
+
+// toStarlark converts a value decoded from YAML or JSON into a Starlark value.
+func toStarlark(v any) (starlark.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(val), nil
+	case string:
+		return starlark.String(val), nil
+	case []byte:
+		return starlark.String(val), nil
+	case int:
+		return starlark.MakeInt(val), nil
+	case int64:
+		return starlark.MakeInt64(val), nil
+	case uint64:
+		return starlark.MakeUint64(val), nil
+	case float64:
+		// Numbers decoded from JSON, such as those of 'value' sources, are
+		// always float64, so integral numbers are converted to ints.
+		if i, ok := normalizeNumbers(val).(int64); ok {
+			return starlark.MakeInt64(i), nil
+		}
+		return starlark.Float(val), nil
+	case map[string]any:
+		// Sort keys, so that iteration order in scripts is deterministic.
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(val))
+		for _, k := range keys {
+			item, err := toStarlark(val[k])
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(k), item); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	case []any:
+		items := make([]starlark.Value, 0, len(val))
+		for _, item := range val {
+			sv, err := toStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, sv)
+		}
+		return starlark.NewList(items), nil
+	default:
+		return nil, fmt.Errorf("cannot convert %T to a Starlark value", v)
+	}
+}
+
+// fromStarlark converts a Starlark value into the Go values produced when
+// decoding YAML or JSON.
+func fromStarlark(v starlark.Value) (any, error) {
+	switch val := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(val), nil
+	case starlark.String:
+		return string(val), nil
+	case starlark.Bytes:
+		return string(val), nil
+	case starlark.Int:
+		if i, ok := val.Int64(); ok {
+			return i, nil
+		}
+		f, _ := new(big.Float).SetInt(val.BigInt()).Float64()
+		return f, nil
+	case starlark.Float:
+		return float64(val), nil
+	case *starlark.Dict:
+		ret := make(map[string]any, val.Len())
+		for _, item := range val.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict keys must be strings, got %s", item[0].Type())
+			}
+			elem, err := fromStarlark(item[1])
+			if err != nil {
+				return nil, err
+			}
+			ret[string(key)] = elem
+		}
+		return ret, nil
+	case *starlarkstruct.Struct:
+		dict := starlark.StringDict{}
+		val.ToStringDict(dict)
+		ret := make(map[string]any, len(dict))
+		for k, item := range dict {
+			elem, err := fromStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			ret[k] = elem
+		}
+		return ret, nil
+	case starlark.Indexable:
+		// Lists and tuples
+		ret := make([]any, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			elem, err := fromStarlark(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, elem)
+		}
+		return ret, nil
+	default:
+		return nil, fmt.Errorf("cannot convert Starlark %s to a value", v.Type())
+	}
+}
@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStarlark(t *testing.T) {
+	store := NewStore(map[string]any{"env": "prod"})
+	err := store.AddReference("manifests", &Result{
+		Format: "yaml",
+		Output: []byte("kind: A\n---\nkind: B\n"),
+	})
+	require.NoError(t, err)
+
+	generate := func(script string) (*Result, error) {
+		return NewStarlark("", config.StarlarkGenerator{
+			Script: config.StringOrValue{String: strPtr(script)},
+		}, store).Generate(context.Background())
+	}
+
+	res, err := generate(`output = {"env": vars["env"], "kinds": [m["kind"] for m in refs["manifests"]]}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"env": "prod", "kinds": []any{"A", "B"}}, res.Output)
+
+	res, err = generate(`
+emit(yaml.decode("a: 1"), json.decode('{"b": [true]}'))
+emit(struct(c = yaml.encode({"d": None})))
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		map[string]any{"a": int64(1)},
+		map[string]any{"b": []any{true}},
+		map[string]any{"c": "d: null\n"},
+	}, res.Output)
+
+	res, err = generate(`output = yaml.decode_all("a: 1\n---\nb: 2\n")`)
+	require.NoError(t, err)
+	assert.Len(t, res.Output, 2)
+
+	_, err = generate(`load("other.star", "x")`)
+	assert.ErrorContains(t, err, "load is not supported")
+
+	_, err = generate(`x = 1`)
+	assert.ErrorContains(t, err, "script did not emit any documents or set 'output'")
+
+	_, err = generate(`output = {1: 2}`)
+	assert.ErrorContains(t, err, "dict keys must be strings")
+
+	_, err = NewStarlark("", config.StarlarkGenerator{
+		Script:   config.StringOrValue{String: strPtr("while True:\n    pass\n")},
+		MaxSteps: 1000,
+	}, store).Generate(context.Background())
+	assert.ErrorContains(t, err, "too many steps")
+}
+
+func TestStarlarkValueNumbers(t *testing.T) {
+	// Numbers in the configuration are decoded as float64.
+	var val config.AnyOrValue
+	require.NoError(t, config.DecodeYAML([]byte("{replicas: 2, ratio: 0.5}"), &val))
+	store := NewStore(nil)
+	res, err := NewValue("", val, store).Generate(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, store.AddReference("v", res))
+
+	res, err = NewStarlark("", config.StarlarkGenerator{
+		Script: config.StringOrValue{String: strPtr(`output = {"names": ["app-%d" % i for i in range(refs["v"]["replicas"])], "ratio": refs["v"]["ratio"]}`)},
+	}, store).Generate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"names": []any{"app-0", "app-1"}, "ratio": 0.5}, res.Output)
+}
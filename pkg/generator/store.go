@@ -1,17 +1,20 @@
 package generator
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"iter"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
+	"sync"
 
+	"github.com/chancez/yamlforge/pkg/cellib"
 	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/google/cel-go/cel"
 )
 
 type Store struct {
@@ -19,15 +22,68 @@ type Store struct {
 	references map[string]*Result
 	// map a variable name to it's value
 	vars map[string]any
+	// interpolate enables ${{ }} expressions in the strings returned by
+	// GetStringValue, for the generators of stages which enable interpolation.
+	interpolate bool
+	// cacheDir is the directory remote inputs are cached in.
+	cacheDir string
+	// offline specifies if remote inputs can only be read from the cache.
+	offline bool
+	// secrets are the values of sensitive values, which are redacted from debug
+	// output and errors.
+	secrets *secrets
 }
 
 func NewStore(vars map[string]any) *Store {
 	return &Store{
 		references: make(map[string]*Result),
 		vars:       vars,
+		secrets:    newSecrets(),
 	}
 }
 
+// newChild returns a store for a sub-pipeline with its own variables and
+// references, which shares the cache and secrets of this store.
+func (store *Store) newChild(vars map[string]any) *Store {
+	child := NewStore(vars)
+	child.SetCache(store.cacheDir, store.offline)
+	child.secrets = store.secrets
+	return child
+}
+
+// Redact replaces the values of sensitive values within s.
+func (store *Store) Redact(s string) string {
+	return store.secrets.redact(s)
+}
+
+// RedactError returns err with the values of sensitive values redacted from
+// its message.
+func (store *Store) RedactError(err error) error {
+	return store.secrets.redactError(err)
+}
+
+// SetCache sets the directory remote inputs, such as http values, are cached
+// in, which defaults to a yamlforge directory in the user cache directory. If
+// offline is true, remote inputs are only read from the cache.
+func (store *Store) SetCache(dir string, offline bool) {
+	store.cacheDir = dir
+	store.offline = offline
+}
+
+// getCacheDir returns the directory to cache remote inputs of the given kind
+// in.
+func (store *Store) getCacheDir(kind string) (string, error) {
+	dir := store.cacheDir
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("error getting cache directory: %w", err)
+		}
+		dir = filepath.Join(userCacheDir, "yamlforge")
+	}
+	return filepath.Join(dir, kind), nil
+}
+
 func (store *Store) AddReference(name string, result *Result) error {
 	if _, exists := store.references[name]; exists {
 		return fmt.Errorf("reference %q already exists", name)
@@ -36,20 +92,20 @@ func (store *Store) AddReference(name string, result *Result) error {
 	return nil
 }
 
-func (store *Store) GetValueBytes(dir string, ref config.Value) ([]byte, error) {
-	ret, err := store.GetValue(dir, ref)
+func (store *Store) GetValueBytes(ctx context.Context, dir string, ref config.Value) ([]byte, error) {
+	ret, err := store.GetValue(ctx, dir, ref)
 	if err != nil {
 		return nil, err
 	}
 	return ConvertToBytes(ret)
 }
 
-func (store *Store) GetAnyValue(dir string, val config.AnyOrValue) (*Result, error) {
+func (store *Store) GetAnyValue(ctx context.Context, dir string, val config.AnyOrValue) (*Result, error) {
 	if val.Any != nil {
 		return &Result{Output: *val.Any}, nil
 	}
 	if val.Value != nil {
-		res, err := store.GetValue(dir, *val.Value)
+		res, err := store.GetValue(ctx, dir, *val.Value)
 		if err != nil {
 			return nil, err
 		}
@@ -58,12 +114,24 @@ func (store *Store) GetAnyValue(dir string, val config.AnyOrValue) (*Result, err
 	return nil, nil
 }
 
-func (store *Store) GetStringValue(dir string, val config.StringOrValue) (string, error) {
+// GetStringValue returns the string of val, interpolating the ${{ }}
+// expressions in it if the store interpolates strings.
+func (store *Store) GetStringValue(ctx context.Context, dir string, val config.StringOrValue) (string, error) {
+	if val.String != nil {
+		return store.interpolateString(*val.String)
+	}
+	return store.GetRawStringValue(ctx, dir, val)
+}
+
+// GetRawStringValue returns the string of val without interpolating it, for
+// fields such as templates, expressions and data which can contain ${{ }}
+// themselves. The file paths of values are still interpolated.
+func (store *Store) GetRawStringValue(ctx context.Context, dir string, val config.StringOrValue) (string, error) {
 	if val.String != nil {
 		return *val.String, nil
 	}
 	if val.Value != nil {
-		v, err := store.GetValue(dir, *val.Value)
+		v, err := store.GetValue(ctx, dir, *val.Value)
 		if err != nil {
 			return "", err
 		}
@@ -79,11 +147,46 @@ func (store *Store) GetStringValue(dir string, val config.StringOrValue) (string
 	return "", nil
 }
 
-func (store *Store) GetStringValueList(dir string, vals []config.StringOrValue) ([]string, error) {
+// withInterpolation returns the store, or a copy of it sharing its references,
+// which interpolates strings if interpolate is true.
+func (store *Store) withInterpolation(interpolate bool) *Store {
+	if store.interpolate == interpolate {
+		return store
+	}
+	copied := *store
+	copied.interpolate = interpolate
+	return &copied
+}
+
+// interpolationEnv returns the CEL environment ${{ }} expressions are
+// evaluated in, which is created when it's first used.
+var interpolationEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cellib.NewEnv()
+})
+
+// interpolateString replaces the ${{ }} CEL expressions in s with their
+// results, evaluated with the pipeline variables and references, if the store
+// interpolates strings.
+func (store *Store) interpolateString(s string) (string, error) {
+	if !store.interpolate || !strings.Contains(s, "${{") {
+		return s, nil
+	}
+	tmpl, err := cellib.ParseTemplate(s)
+	if err != nil {
+		return "", err
+	}
+	env, err := interpolationEnv()
+	if err != nil {
+		return "", fmt.Errorf("error creating CEL environment: %w", err)
+	}
+	return tmpl.Execute(env, newCELVariables(store).activation(nil))
+}
+
+func (store *Store) GetStringValueList(ctx context.Context, dir string, vals []config.StringOrValue) ([]string, error) {
 	var ret []string
 	if len(vals) != 0 {
 		for _, val := range vals {
-			sv, err := store.GetStringValue(dir, val)
+			sv, err := store.GetStringValue(ctx, dir, val)
 			if err != nil {
 				return nil, err
 			}
@@ -93,12 +196,12 @@ func (store *Store) GetStringValueList(dir string, vals []config.StringOrValue)
 	return ret, nil
 }
 
-func (store *Store) GetBoolValue(dir string, val config.BoolOrValue) (bool, error) {
+func (store *Store) GetBoolValue(ctx context.Context, dir string, val config.BoolOrValue) (bool, error) {
 	if val.Bool != nil {
 		return *val.Bool, nil
 	}
 	if val.Value != nil {
-		v, err := store.GetValue(dir, *val.Value)
+		v, err := store.GetValue(ctx, dir, *val.Value)
 		if err != nil {
 			return false, err
 		}
@@ -119,18 +222,21 @@ func (store *Store) GetBoolValue(dir string, val config.BoolOrValue) (bool, erro
 	return false, nil
 }
 
-func (store *Store) GetMapValue(dir string, val config.MapOrValue) (map[string]any, error) {
+func (store *Store) GetMapValue(ctx context.Context, dir string, val config.MapOrValue) (map[string]any, error) {
 	if val.Map != nil {
 		return val.Map, nil
 	}
 	if val.Value != nil {
-		v, err := store.GetValue(dir, *val.Value)
+		v, err := store.GetValue(ctx, dir, *val.Value)
 		if err != nil {
 			return nil, err
 		}
 		if mapVal, ok := v.Output.(map[string]any); ok {
 			return mapVal, nil
 		}
+		if v.Format != "" && v.Format != "yaml" {
+			return parseMapResult(v)
+		}
 
 		data, err := ConvertToBytes(v)
 		if err != nil {
@@ -146,9 +252,29 @@ func (store *Store) GetMapValue(dir string, val config.MapOrValue) (map[string]a
 	return nil, nil
 }
 
-func (store *Store) GetValue(dir string, ref config.Value) (*Result, error) {
-	if ref.Format != "" {
-		items, err := store.GetParsedValues(dir, ref)
+// parseMapResult parses the first document of res using its format, which
+// must be an object.
+func parseMapResult(res *Result) (map[string]any, error) {
+	vals, err := parseResult(res, "")
+	if err != nil {
+		return nil, err
+	}
+	for val, err := range vals {
+		if err != nil {
+			return nil, err
+		}
+		m, ok := val.Parsed().(map[string]any)
+		if !ok && val.Parsed() != nil {
+			return nil, fmt.Errorf("expected an object, got %T", val.Parsed())
+		}
+		return m, nil
+	}
+	return nil, nil
+}
+
+func (store *Store) GetValue(ctx context.Context, dir string, ref config.Value) (*Result, error) {
+	if ref.Format != "" && !hasPath(ref) {
+		items, err := store.GetParsedValues(ctx, dir, ref)
 		if err != nil {
 			return nil, err
 		}
@@ -164,10 +290,38 @@ func (store *Store) GetValue(dir string, ref config.Value) (*Result, error) {
 		}
 		return &Result{Output: res}, nil
 	}
-	return store.getValue(dir, ref)
+	return store.getValue(ctx, dir, ref)
 }
 
-func (store *Store) getValue(dir string, ref config.Value) (*Result, error) {
+func (store *Store) getValue(ctx context.Context, dir string, ref config.Value) (*Result, error) {
+	res, err := store.resolveValue(ctx, dir, ref)
+	if err == nil && isSensitive(ref) {
+		store.addSecrets(res)
+	}
+	if err != nil || !hasPath(ref) {
+		return res, err
+	}
+	return extractValue(res, ref)
+}
+
+// addSecrets records the output of res as secrets, including each value
+// within it if it can be parsed.
+func (store *Store) addSecrets(res *Result) {
+	if res == nil {
+		return
+	}
+	store.secrets.add(res.Output)
+	if parsed, err := parseReference(res); err == nil {
+		store.secrets.add(parsed)
+	}
+}
+
+// hasPath reports if ref selects a sub-value using path or select.
+func hasPath(ref config.Value) bool {
+	return ref.Path != "" || ref.Select != ""
+}
+
+func (store *Store) resolveValue(ctx context.Context, dir string, ref config.Value) (*Result, error) {
 	switch {
 	case ref.Var != "":
 		varName := ref.Var
@@ -192,17 +346,53 @@ func (store *Store) getValue(dir string, ref config.Value) (*Result, error) {
 		}
 		return res, nil
 	case ref.File != "":
-		res, err := os.ReadFile(path.Join(dir, ref.File))
+		file, err := store.interpolateString(ref.File)
+		if err != nil {
+			return nil, fmt.Errorf("error interpolating file: %w", err)
+		}
+		res, err := os.ReadFile(path.Join(dir, file))
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) && ref.IgnoreMissing {
 				return &Result{Output: ref.Default}, nil
 			}
-			return nil, fmt.Errorf("error opening file %q", ref.File)
+			return nil, fmt.Errorf("error opening file %q", file)
 		}
-		format := formatFromFileName(ref.File)
+		format := formatFromFileName(file)
 		return &Result{Output: res, Format: format}, nil
+	case ref.Glob != "" || ref.Dir != "":
+		var err error
+		if ref.Glob, err = store.interpolateString(ref.Glob); err != nil {
+			return nil, fmt.Errorf("error interpolating glob: %w", err)
+		}
+		if ref.Dir, err = store.interpolateString(ref.Dir); err != nil {
+			return nil, fmt.Errorf("error interpolating dir: %w", err)
+		}
+		return getFilesValue(dir, ref)
+	case ref.Git != nil:
+		return store.getGitValue(ctx, dir, ref)
+	case ref.SOPS != nil:
+		res, err := store.getSOPSValue(ctx, dir, ref)
+		if err != nil {
+			return nil, fmt.Errorf("error getting value: %w", err)
+		}
+		return res, nil
+	case ref.Age != nil:
+		res, err := store.getAgeValue(ctx, dir, ref)
+		if err != nil {
+			return nil, fmt.Errorf("error getting value: %w", err)
+		}
+		return res, nil
+	case ref.HTTP != nil:
+		res, err := NewHTTP(dir, *ref.HTTP, store).Generate(ctx)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && ref.IgnoreMissing {
+				return &Result{Output: ref.Default}, nil
+			}
+			return nil, fmt.Errorf("error getting value: %w", err)
+		}
+		return res, nil
 	case ref.Value != nil:
-		ret, err := store.GetAnyValue(dir, *ref.Value)
+		ret, err := store.GetAnyValue(ctx, dir, *ref.Value)
 		if err != nil {
 			return nil, fmt.Errorf("error getting value: %w", err)
 		}
@@ -210,11 +400,15 @@ func (store *Store) getValue(dir string, ref config.Value) (*Result, error) {
 	case ref.Values != nil:
 		var vals []any
 		for _, v := range ref.Values {
-			ret, err := store.GetAnyValue(dir, v)
+			ret, err := store.GetAnyValue(ctx, dir, v)
 			if err != nil {
 				return nil, fmt.Errorf("error getting value: %w", err)
 			}
-			vals = append(vals, ret)
+			if ret == nil {
+				vals = append(vals, nil)
+				continue
+			}
+			vals = append(vals, ret.Output)
 		}
 		return &Result{Output: vals}, nil
 	case ref.PipelineGenerator != nil:
@@ -223,7 +417,7 @@ func (store *Store) getValue(dir string, ref config.Value) (*Result, error) {
 			return nil, fmt.Errorf("error getting value: %w", err)
 		}
 		subPipeline := NewPipeline(dir, *ref.PipelineGenerator, store, false)
-		res, err := subPipeline.Generate(context.TODO())
+		res, err := subPipeline.Generate(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("error getting value: %w", err)
 		}
@@ -233,6 +427,87 @@ func (store *Store) getValue(dir string, ref config.Value) (*Result, error) {
 	}
 }
 
+// parsedReferences returns the output of each reference, parsed using
+// parseReference.
+func (store *Store) parsedReferences() (map[string]any, error) {
+	refs := make(map[string]any, len(store.references))
+	for name, res := range store.references {
+		val, err := parseReference(res)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing reference %q: %w", name, err)
+		}
+		refs[name] = val
+	}
+	return refs, nil
+}
+
+// parseReference parses the output of a reference using its format if it's
+// not already parsed. Outputs with multiple documents are returned as a list,
+// and outputs without a format are returned as strings.
+func parseReference(res *Result) (any, error) {
+	if res == nil {
+		return nil, nil
+	}
+	switch v := res.Output.(type) {
+	case []byte:
+		if res.Format == "" {
+			return string(v), nil
+		}
+	case string:
+		if res.Format == "" {
+			return v, nil
+		}
+	default:
+		return v, nil
+	}
+	vals, err := parseResult(res, "")
+	if err != nil {
+		return nil, err
+	}
+	var docs []any
+	for val, err := range vals {
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, val.Parsed())
+	}
+	if len(docs) == 1 {
+		return docs[0], nil
+	}
+	return docs, nil
+}
+
+// describeValue returns a short description of where val is retrieved from,
+// for use in error messages.
+func describeValue(val config.Value) string {
+	switch {
+	case val.Var != "":
+		return fmt.Sprintf("var %q", val.Var)
+	case val.Env != "":
+		return fmt.Sprintf("env %q", val.Env)
+	case val.Ref != "":
+		return fmt.Sprintf("ref %q", val.Ref)
+	case val.File != "":
+		return fmt.Sprintf("file %q", val.File)
+	case val.Glob != "":
+		return fmt.Sprintf("glob %q", val.Glob)
+	case val.Dir != "":
+		return fmt.Sprintf("dir %q", val.Dir)
+	case val.HTTP != nil:
+		return "http"
+	case val.Git != nil:
+		return "git"
+	case val.SOPS != nil:
+		return "sops"
+	case val.Age != nil:
+		return "age"
+	case val.PipelineGenerator != nil:
+		return "pipeline"
+	default:
+		return "value"
+	}
+}
+
 // ParsedValue stores the parsed Value retrieved from the store, and the original data before parsing.
 type ParsedValue struct {
 	parsed any
@@ -247,7 +522,7 @@ func (pv ParsedValue) Parsed() any {
 	return pv.parsed
 }
 
-func (store *Store) getParsedValueDecoder(data []byte, format string) (Decoder, error) {
+func getParsedValueDecoder(data []byte, format string) (Decoder, error) {
 	dec, err := NewDecoder(format, data)
 	if err != nil {
 		return nil, fmt.Errorf("error creating decoder: %w", err)
@@ -255,12 +530,22 @@ func (store *Store) getParsedValueDecoder(data []byte, format string) (Decoder,
 	return dec, nil
 }
 
-func (store *Store) GetParsedValues(dir string, val config.Value) (iter.Seq2[ParsedValue, error], error) {
-	res, err := store.getValue(dir, val)
+func (store *Store) GetParsedValues(ctx context.Context, dir string, val config.Value) (iter.Seq2[ParsedValue, error], error) {
+	res, err := store.getValue(ctx, dir, val)
 	if err != nil {
 		return nil, err
 	}
+	if hasPath(val) {
+		// The extracted value is already parsed.
+		return convertToParsedValueIter(res)
+	}
+	return parseResult(res, val.Format)
+}
 
+// parseResult returns an iterator over the documents in res. If the output of
+// res is not already parsed, it's parsed using the format of the result,
+// falling back to defaultFormat if the result has no format.
+func parseResult(res *Result, defaultFormat string) (iter.Seq2[ParsedValue, error], error) {
 	var data []byte
 	switch v := res.Output.(type) {
 	case string:
@@ -268,17 +553,17 @@ func (store *Store) GetParsedValues(dir string, val config.Value) (iter.Seq2[Par
 	case []byte:
 		data = v
 	default:
-		return store.convertToParsedValueIter(res)
+		return convertToParsedValueIter(res)
 	}
 
 	format := res.Format
 	if format == "" {
-		format = val.Format
+		format = defaultFormat
 	}
 	if format == "" {
 		return nil, fmt.Errorf("unknown format, cannot parse without format set")
 	}
-	dec, err := store.getParsedValueDecoder(data, format)
+	dec, err := getParsedValueDecoder(data, format)
 	if err != nil {
 		return nil, err
 	}
@@ -298,7 +583,7 @@ func (store *Store) GetParsedValues(dir string, val config.Value) (iter.Seq2[Par
 	}, nil
 }
 
-func (store *Store) convertToParsedValueIter(val *Result) (iter.Seq2[ParsedValue, error], error) {
+func convertToParsedValueIter(val *Result) (iter.Seq2[ParsedValue, error], error) {
 	return func(yield func(ParsedValue, error) bool) {
 		var items []any
 		if vals, ok := val.Output.([]any); ok {
@@ -322,16 +607,11 @@ type Decoder interface {
 }
 
 func NewDecoder(format string, data []byte) (Decoder, error) {
-	switch format {
-	case "yaml":
-		return config.NewYAMLDecoder(bytes.NewBuffer(data)), nil
-	case "json":
-		return json.NewDecoder(bytes.NewBuffer(data)), nil
-	case "":
-		return nil, errors.New("input.format is required")
-	default:
-		return nil, fmt.Errorf("invalid input format specified: %q", format)
+	c, err := GetCodec(format)
+	if err != nil {
+		return nil, err
 	}
+	return c.NewDecoder(data)
 }
 
 func ConvertToBytes(res *Result) ([]byte, error) {
@@ -344,6 +624,30 @@ func ConvertToBytes(res *Result) ([]byte, error) {
 	case []byte:
 		return val, nil
 	default:
+		if res.Format != "" && res.Format != "yaml" {
+			return encodeDocuments(res.Format, []any{res.Output})
+		}
 		return config.EncodeYAML(res.Output)
 	}
 }
+
+// ConvertToYAML returns the output of res like ConvertToBytes, but formats
+// parsed outputs and YAML according to format.
+func ConvertToYAML(res *Result, format config.YAMLFormat) ([]byte, error) {
+	if res == nil || res.Output == nil {
+		return nil, nil
+	}
+	switch res.Output.(type) {
+	case string, []byte:
+		data, err := ConvertToBytes(res)
+		if err != nil || res.Format != "yaml" {
+			return data, err
+		}
+		return config.FormatYAML(data, format)
+	default:
+		if res.Format != "" && res.Format != "yaml" {
+			return ConvertToBytes(res)
+		}
+		return config.EncodeYAMLDocuments([]any{res.Output}, format)
+	}
+}
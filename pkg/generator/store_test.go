@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"context"
 	"os"
 	"path"
 	"testing"
@@ -23,41 +24,41 @@ func TestStore(t *testing.T) {
 	require.Error(t, err)
 
 	// Looking up references
-	refData, err := store.GetValueBytes("", config.Value{
+	refData, err := store.GetValueBytes(context.Background(), "", config.Value{
 		Ref: "example",
 	})
 	require.NoError(t, err)
 	assert.Equal(t, []byte(`ref-data`), refData)
 
 	// Invalid refs should return an error
-	_, err = store.GetValueBytes("", config.Value{
+	_, err = store.GetValueBytes(context.Background(), "", config.Value{
 		Ref: "does not exist",
 	})
 	require.Error(t, err)
 
 	// Test variables lookup
-	varData, err := store.GetValueBytes("", config.Value{
+	varData, err := store.GetValueBytes(context.Background(), "", config.Value{
 		Var: "some-var",
 	})
 	require.NoError(t, err)
 	assert.Equal(t, []byte(`var-data`), varData)
 
 	// Invalid variables should return an error
-	_, err = store.GetValueBytes("", config.Value{
+	_, err = store.GetValueBytes(context.Background(), "", config.Value{
 		Var: "does not exist",
 	})
 	require.Error(t, err)
 
 	// Values should be returned as their YAML encoded value
 	strVal := any("string-val")
-	valData, err := store.GetValueBytes("", config.Value{
+	valData, err := store.GetValueBytes(context.Background(), "", config.Value{
 		Value: &config.AnyOrValue{Any: &strVal},
 	})
 	require.NoError(t, err)
 	assert.Equal(t, []byte(`string-val`), valData)
 
 	boolVal := any(true)
-	valData2, err := store.GetValueBytes("", config.Value{
+	valData2, err := store.GetValueBytes(context.Background(), "", config.Value{
 		Value: &config.AnyOrValue{Any: &boolVal},
 	})
 	require.NoError(t, err)
@@ -65,18 +66,29 @@ func TestStore(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, trueBytes, valData2)
 
+	// Values should be returned as a list of their outputs
+	listVal, err := store.GetValue(context.Background(), "", config.Value{
+		Values: []config.AnyOrValue{
+			{Any: &strVal},
+			{Value: &config.Value{Var: "some-var"}},
+			{},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"string-val", "var-data", nil}, listVal.Output)
+
 	tmpDir := t.TempDir()
 	err = os.WriteFile(path.Join(tmpDir, "example.txt"), []byte(`some-file-data`), 0640)
 	require.NoError(t, err)
 
-	fileData, err := store.GetValueBytes(tmpDir, config.Value{
+	fileData, err := store.GetValueBytes(context.Background(), tmpDir, config.Value{
 		File: "example.txt",
 	})
 	require.NoError(t, err)
 	assert.Equal(t, []byte(`some-file-data`), fileData)
 
 	// Look up an existing ref as a string
-	strData, err := store.GetStringValue("", config.StringOrValue{
+	strData, err := store.GetStringValue(context.Background(), "", config.StringOrValue{
 		Value: &config.Value{
 			Ref: "example",
 		},
@@ -86,7 +98,7 @@ func TestStore(t *testing.T) {
 
 	// Call GetStringValue on a non-ref value
 	exampleStr := "example-str"
-	strData2, err := store.GetStringValue("", config.StringOrValue{
+	strData2, err := store.GetStringValue(context.Background(), "", config.StringOrValue{
 		String: &exampleStr,
 	})
 	require.NoError(t, err)
@@ -97,7 +109,7 @@ func TestStore(t *testing.T) {
 	require.NoError(t, err)
 
 	// Look up an existing ref as a bool
-	boolData, err := store.GetBoolValue("", config.BoolOrValue{
+	boolData, err := store.GetBoolValue(context.Background(), "", config.BoolOrValue{
 		Value: &config.Value{
 			Ref: "bool-ref",
 		},
@@ -107,9 +119,211 @@ func TestStore(t *testing.T) {
 
 	// Call GetBoolValue on a non-ref value
 	exampleBool := true
-	boolData2, err := store.GetBoolValue("", config.BoolOrValue{
+	boolData2, err := store.GetBoolValue(context.Background(), "", config.BoolOrValue{
 		Bool: &exampleBool,
 	})
 	require.NoError(t, err)
 	assert.Equal(t, true, boolData2)
 }
+
+func TestStoreInterpolation(t *testing.T) {
+	store := NewStore(map[string]any{
+		"env": "prod",
+	})
+	err := store.AddReference("settings", &Result{Output: map[string]any{"name": "app"}})
+	require.NoError(t, err)
+
+	tmpDir := t.TempDir()
+	err = os.WriteFile(path.Join(tmpDir, "app.txt"), []byte(`app-file-data`), 0640)
+	require.NoError(t, err)
+
+	str := "${{ refs.settings.name }}-${{ vars.env }} $${{ vars.env }}"
+	strVal := config.StringOrValue{String: &str}
+	fileVal := config.Value{File: "${{ refs.settings.name }}.txt"}
+
+	// Strings are only interpolated by stores which enable interpolation.
+	s, err := store.GetStringValue(context.Background(), "", strVal)
+	require.NoError(t, err)
+	assert.Equal(t, str, s)
+	_, err = store.GetValueBytes(context.Background(), tmpDir, fileVal)
+	require.Error(t, err)
+
+	interpolating := store.withInterpolation(true)
+	s, err = interpolating.GetStringValue(context.Background(), "", strVal)
+	require.NoError(t, err)
+	assert.Equal(t, "app-prod ${{ vars.env }}", s)
+
+	// Raw strings are never interpolated, but file paths are.
+	s, err = interpolating.GetRawStringValue(context.Background(), "", strVal)
+	require.NoError(t, err)
+	assert.Equal(t, str, s)
+	fileData, err := interpolating.GetValueBytes(context.Background(), tmpDir, fileVal)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`app-file-data`), fileData)
+	globData, err := interpolating.GetValue(context.Background(), tmpDir, config.Value{Glob: "${{ refs.settings.name }}.*", AsMap: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"app.txt": "app-file-data"}, globData.Output)
+
+	// The copy shares the references of the store.
+	err = store.AddReference("later", &Result{Output: "added"})
+	require.NoError(t, err)
+	later := "${{ refs.later }}"
+	s, err = interpolating.GetStringValue(context.Background(), "", config.StringOrValue{String: &later})
+	require.NoError(t, err)
+	assert.Equal(t, "added", s)
+}
+
+func TestStorePath(t *testing.T) {
+	store := NewStore(map[string]any{
+		"config": map[string]any{"a/b": "slash", "ports": []any{80, 443}},
+	})
+	err := store.AddReference("deploy", &Result{
+		Output: []byte("kind: Deployment\nspec:\n  replicas: 3\n  containers:\n  - name: app\n  - name: sidecar\n---\nkind: Service\n"),
+		Format: "yaml",
+	})
+	require.NoError(t, err)
+	err = store.AddReference("json", &Result{Output: `{"name": "app"}`})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		value    config.Value
+		expected any
+		err      string
+	}{
+		{
+			name:     "json pointer",
+			value:    config.Value{Ref: "deploy", Path: "/0/spec/replicas"},
+			expected: uint64(3),
+		},
+		{
+			name:     "escaped json pointer",
+			value:    config.Value{Var: "config", Path: "/a~1b"},
+			expected: "slash",
+		},
+		{
+			name:     "dotted path",
+			value:    config.Value{Var: "config", Path: "ports.1"},
+			expected: 443,
+		},
+		{
+			name:     "format",
+			value:    config.Value{Ref: "json", Path: "/name", Format: "json"},
+			expected: "app",
+		},
+		{
+			name:     "singular select",
+			value:    config.Value{Ref: "deploy", Select: "$[1].kind"},
+			expected: "Service",
+		},
+		{
+			name:     "select",
+			value:    config.Value{Ref: "deploy", Select: "$[*].spec.containers[*].name"},
+			expected: []any{"app", "sidecar"},
+		},
+		{
+			name:     "path and select",
+			value:    config.Value{Ref: "deploy", Path: "/0/spec", Select: "$.containers[0].name"},
+			expected: "app",
+		},
+		{
+			name:     "select without matches",
+			value:    config.Value{Ref: "deploy", Select: "$[*].metadata"},
+			expected: []any{},
+		},
+		{
+			name:  "missing path",
+			value: config.Value{Ref: "deploy", Path: "/0/metadata/name"},
+			err:   `path "/0/metadata/name": not found`,
+		},
+		{
+			name:  "missing select",
+			value: config.Value{Ref: "deploy", Select: "$[0].metadata"},
+			err:   `select "$[0].metadata": not found`,
+		},
+		{
+			name:     "ignore missing path",
+			value:    config.Value{Ref: "deploy", Path: "/5", IgnoreMissing: true, Default: "default"},
+			expected: "default",
+		},
+		{
+			name:  "invalid index",
+			value: config.Value{Var: "config", Path: "/ports/first"},
+			err:   `invalid path "/ports/first": invalid array index "first"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := store.GetValue(context.Background(), "", tt.value)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, res.Output)
+		})
+	}
+
+	// Each match is a separate document when parsed.
+	vals, err := store.GetParsedValues(context.Background(), "", config.Value{Ref: "deploy", Select: "$[*].kind"})
+	require.NoError(t, err)
+	var docs []any
+	for val, err := range vals {
+		require.NoError(t, err)
+		docs = append(docs, val.Parsed())
+	}
+	assert.Equal(t, []any{"Deployment", "Service"}, docs)
+}
+
+func TestStoreFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(tmpDir, "manifests", "nested"), 0750))
+	files := map[string]string{
+		"manifests/a.yaml":        "name: a\n---\nname: b\n",
+		"manifests/c.json":        `{"name": "c"}`,
+		"manifests/nested/d.yaml": "name: d\n",
+		"manifests/notes.txt":     "name: notes\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(path.Join(tmpDir, name), []byte(content), 0640))
+	}
+	store := NewStore(nil)
+
+	res, err := store.GetValue(context.Background(), tmpDir, config.Value{Glob: "manifests/*.*ml"})
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}, res.Output)
+
+	// Files with an unknown extension are parsed using format.
+	res, err = store.GetValue(context.Background(), tmpDir, config.Value{Dir: "manifests", Format: "yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		map[string]any{"name": "a"},
+		map[string]any{"name": "b"},
+		map[string]any{"name": "c"},
+		map[string]any{"name": "d"},
+		map[string]any{"name": "notes"},
+	}, res.Output)
+
+	_, err = store.GetValue(context.Background(), tmpDir, config.Value{Dir: "manifests"})
+	assert.ErrorContains(t, err, "unknown format for file")
+
+	res, err = store.GetValue(context.Background(), tmpDir, config.Value{Dir: "manifests", AsMap: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"a.yaml":        files["manifests/a.yaml"],
+		"c.json":        files["manifests/c.json"],
+		"nested/d.yaml": files["manifests/nested/d.yaml"],
+		"notes.txt":     files["manifests/notes.txt"],
+	}, res.Output)
+
+	res, err = store.GetValue(context.Background(), tmpDir, config.Value{Glob: "manifests/*/*.yaml", AsMap: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"nested/d.yaml": "name: d\n"}, res.Output)
+
+	_, err = store.GetValue(context.Background(), tmpDir, config.Value{Glob: "missing/*.yaml"})
+	assert.EqualError(t, err, `glob "missing/*.yaml" matched no files: file does not exist`)
+
+	res, err = store.GetValue(context.Background(), tmpDir, config.Value{Dir: "missing", IgnoreMissing: true, Default: "default"})
+	require.NoError(t, err)
+	assert.Equal(t, "default", res.Output)
+}
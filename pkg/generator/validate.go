@@ -0,0 +1,281 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/chancez/yamlforge/pkg/k8s"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+var _ Generator = (*Validate)(nil)
+
+type Validate struct {
+	dir      string
+	cfg      config.ValidateGenerator
+	refStore *Store
+}
+
+func NewValidate(dir string, cfg config.ValidateGenerator, refStore *Store) *Validate {
+	return &Validate{
+		dir:      dir,
+		cfg:      cfg,
+		refStore: refStore,
+	}
+}
+
+// gvk identifies the schema of a Kubernetes resource.
+type gvk struct {
+	apiVersion string
+	kind       string
+}
+
+// kubernetesSchemas looks up and caches the schemas of Kubernetes resources.
+type kubernetesSchemas struct {
+	version    string
+	schemaDirs []string
+	strict     bool
+	// crds are the parsed, but not yet compiled schemas defined by
+	// CustomResourceDefinitions.
+	crds     map[gvk]any
+	compiled map[gvk]*jsonschema.Schema
+}
+
+func (v *Validate) Generate(ctx context.Context) (*Result, error) {
+	version, err := v.refStore.GetStringValue(ctx, v.dir, v.cfg.KubernetesVersion)
+	if err != nil {
+		return nil, fmt.Errorf("error getting kubernetesVersion: %w", err)
+	}
+	if version == "" {
+		version = "master"
+	} else if version != "master" && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	schemaDirs, err := v.refStore.GetStringValueList(ctx, v.dir, v.cfg.SchemaDirs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting schemaDirs: %w", err)
+	}
+	for i, dir := range schemaDirs {
+		schemaDirs[i] = filepath.Join(v.dir, dir)
+	}
+	strict, err := v.refStore.GetBoolValue(ctx, v.dir, v.cfg.Strict)
+	if err != nil {
+		return nil, fmt.Errorf("error getting strict: %w", err)
+	}
+	ignoreMissingSchemas, err := v.refStore.GetBoolValue(ctx, v.dir, v.cfg.IgnoreMissingSchemas)
+	if err != nil {
+		return nil, fmt.Errorf("error getting ignoreMissingSchemas: %w", err)
+	}
+
+	schemas := &kubernetesSchemas{
+		version:    version,
+		schemaDirs: schemaDirs,
+		strict:     strict,
+		crds:       make(map[gvk]any),
+		compiled:   make(map[gvk]*jsonschema.Schema),
+	}
+	for i, input := range v.cfg.CRDs {
+		vals, err := v.refStore.GetParsedValues(ctx, v.dir, input)
+		if err != nil {
+			return nil, fmt.Errorf("crds[%d]: error getting value: %w", i, err)
+		}
+		for val, err := range vals {
+			if err != nil {
+				return nil, fmt.Errorf("crds[%d]: error while processing input: %w", i, err)
+			}
+			if obj, ok := val.Parsed().(map[string]any); ok {
+				schemas.addCRD(obj)
+			}
+		}
+	}
+
+	type sourcedDoc struct {
+		source string
+		doc    any
+	}
+	var docs []sourcedDoc
+	for i, input := range v.cfg.Input {
+		vals, err := v.refStore.GetParsedValues(ctx, v.dir, input)
+		if err != nil {
+			return nil, fmt.Errorf("error getting value: %w", err)
+		}
+		source := fmt.Sprintf("input[%d] (%s)", i, describeValue(input))
+		for val, err := range vals {
+			if err != nil {
+				return nil, fmt.Errorf("%s: error while processing input: %w", source, err)
+			}
+			if val.Parsed() == nil {
+				continue
+			}
+			if obj, ok := val.Parsed().(map[string]any); ok {
+				schemas.addCRD(obj)
+			}
+			docs = append(docs, sourcedDoc{source: source, doc: val.Parsed()})
+		}
+	}
+
+	var errs []error
+	var output []any
+	for _, item := range docs {
+		output = append(output, item.doc)
+		obj, ok := item.doc.(map[string]any)
+		apiVersion, _ := obj["apiVersion"].(string)
+		kind, _ := obj["kind"].(string)
+		if !ok || apiVersion == "" || kind == "" {
+			errs = append(errs, fmt.Errorf("%s: document is not a Kubernetes resource, apiVersion and kind are required", item.source))
+			continue
+		}
+		resource := apiVersion + " " + kind
+		if id, ok := k8s.GetResourceID(obj); ok {
+			resource = id.String()
+		}
+		sch, err := schemas.get(gvk{apiVersion: apiVersion, kind: kind})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", item.source, resource, err))
+			continue
+		}
+		if sch == nil {
+			if !ignoreMissingSchemas {
+				errs = append(errs, fmt.Errorf("%s: %s: could not find schema", item.source, resource))
+			}
+			continue
+		}
+		violations, err := validateSchema(sch, obj)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", item.source, resource, err))
+			continue
+		}
+		for _, violation := range violations {
+			errs = append(errs, fmt.Errorf("%s: %s: %s", item.source, resource, violation))
+		}
+	}
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("validation failed:\n%w", errors.Join(errs...))
+	}
+	return &Result{Output: output}, nil
+}
+
+// addCRD stores the schemas of each version of obj if it's a CustomResourceDefinition.
+func (s *kubernetesSchemas) addCRD(obj map[string]any) {
+	if obj["kind"] != "CustomResourceDefinition" {
+		return
+	}
+	group, _ := k8s.NestedMap(obj, "spec")["group"].(string)
+	kind, _ := k8s.NestedMap(obj, "spec", "names")["kind"].(string)
+	// apiextensions.k8s.io/v1beta1 CRDs may define a single schema for all versions.
+	defaultSchema := k8s.NestedMap(obj, "spec", "validation", "openAPIV3Schema")
+	for _, version := range k8s.NestedMaps(obj, "spec", "versions") {
+		name, _ := version["name"].(string)
+		schema := k8s.NestedMap(version, "schema", "openAPIV3Schema")
+		if schema == nil {
+			schema = defaultSchema
+		}
+		if name == "" || schema == nil {
+			continue
+		}
+		s.crds[gvk{apiVersion: group + "/" + name, kind: kind}] = openAPIToJSONSchema(schema)
+	}
+}
+
+// get returns the compiled schema for a resource, or nil if no schema exists.
+func (s *kubernetesSchemas) get(key gvk) (*jsonschema.Schema, error) {
+	if sch, ok := s.compiled[key]; ok {
+		return sch, nil
+	}
+
+	var (
+		url string
+		doc any
+	)
+	if crdSchema, ok := s.crds[key]; ok {
+		url = fmt.Sprintf("crd:///%s/%s", key.apiVersion, key.kind)
+		doc = crdSchema
+	} else {
+		for _, dir := range s.schemaDirs {
+			p := filepath.Join(dir, s.version+s.dirSuffix(), kubernetesSchemaFileName(key))
+			f, err := os.Open(p)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error reading schema: %w", err)
+			}
+			doc, err = jsonschema.UnmarshalJSON(f)
+			// nolint:errcheck
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("error parsing schema %q: %w", p, err)
+			}
+			if m, ok := doc.(map[string]any); ok {
+				// The Kubernetes schemas use a non-standard $schema URL which
+				// would need to be fetched.
+				delete(m, "$schema")
+			}
+			url = p
+			break
+		}
+	}
+
+	var sch *jsonschema.Schema
+	if doc != nil {
+		var err error
+		sch, err = compileSchema(url, doc)
+		if err != nil {
+			return nil, fmt.Errorf("error compiling schema: %w", err)
+		}
+	}
+	s.compiled[key] = sch
+	return sch, nil
+}
+
+func (s *kubernetesSchemas) dirSuffix() string {
+	if s.strict {
+		return "-standalone-strict"
+	}
+	return "-standalone"
+}
+
+// kubernetesSchemaFileName returns the file name of the JSON schema for a
+// resource, for example deployment-apps-v1.json.
+func kubernetesSchemaFileName(key gvk) string {
+	group, version, found := strings.Cut(key.apiVersion, "/")
+	if !found {
+		group, version = "", key.apiVersion
+	}
+	name := strings.ToLower(key.kind)
+	if group != "" {
+		name += "-" + strings.ToLower(strings.Split(group, ".")[0])
+	}
+	return name + "-" + version + ".json"
+}
+
+// openAPIToJSONSchema converts an OpenAPI v3 schema to a JSON schema by
+// converting nullable fields to allow null values.
+func openAPIToJSONSchema(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		ret := make(map[string]any, len(val))
+		for k, item := range val {
+			ret[k] = openAPIToJSONSchema(item)
+		}
+		if nullable, _ := ret["nullable"].(bool); nullable {
+			if typ, ok := ret["type"].(string); ok {
+				ret["type"] = []any{typ, "null"}
+			}
+		}
+		return ret
+	case []any:
+		ret := make([]any, len(val))
+		for i, item := range val {
+			ret[i] = openAPIToJSONSchema(item)
+		}
+		return ret
+	default:
+		return v
+	}
+}
@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	schemaDir := filepath.Join(dir, "schemas", "master-standalone")
+	require.NoError(t, os.MkdirAll(schemaDir, 0750))
+	err := os.WriteFile(filepath.Join(schemaDir, "deployment-apps-v1.json"), []byte(`{
+  "$schema": "http://json-schema.org/schema#",
+  "type": "object",
+  "properties": {
+    "spec": {
+      "type": "object",
+      "properties": {
+        "replicas": {"type": "integer"}
+      }
+    }
+  }
+}`), 0640)
+	require.NoError(t, err)
+
+	store := NewStore(nil)
+	err = store.AddReference("resources", &Result{
+		Format: "yaml",
+		Output: []byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: databases.example.com
+spec:
+  group: example.com
+  names:
+    kind: Database
+  versions:
+    - name: v1
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              properties:
+                engine:
+                  type: string
+                  enum: [postgres]
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: valid
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: invalid
+  namespace: default
+spec:
+  replicas: three
+---
+apiVersion: example.com/v1
+kind: Database
+metadata:
+  name: db
+spec:
+  engine: oracle
+`),
+	})
+	require.NoError(t, err)
+
+	input := []config.Value{{Ref: "resources"}}
+	schemaDirs := []config.StringOrValue{{String: strPtr("schemas")}}
+
+	_, err = NewValidate(dir, config.ValidateGenerator{
+		Input:      input,
+		SchemaDirs: schemaDirs,
+	}, store).Generate(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `input[0] (ref "resources"): apps/v1 Deployment default/invalid: /spec/replicas: got string, want integer`)
+	assert.Contains(t, err.Error(), `input[0] (ref "resources"): example.com/v1 Database db: /spec/engine: value must be 'postgres'`)
+	// The CustomResourceDefinition itself has no schema.
+	assert.Contains(t, err.Error(), `apiextensions.k8s.io/v1 CustomResourceDefinition databases.example.com: could not find schema`)
+	assert.NotContains(t, err.Error(), "Deployment valid")
+
+	_, err = NewValidate(dir, config.ValidateGenerator{
+		Input:                input,
+		SchemaDirs:           schemaDirs,
+		IgnoreMissingSchemas: config.BoolOrValue{Bool: boolPtr(true)},
+		Strict:               config.BoolOrValue{Bool: boolPtr(true)},
+	}, store).Generate(context.Background())
+	require.Error(t, err)
+	// There are no strict schemas, so only the custom resource is validated.
+	assert.Contains(t, err.Error(), "example.com/v1 Database db")
+	assert.NotContains(t, err.Error(), "Deployment")
+	assert.NotContains(t, err.Error(), "CustomResourceDefinition")
+}
@@ -22,8 +22,8 @@ func NewValue(dir string, val config.AnyOrValue, refStore *Store) *Value {
 	}
 }
 
-func (v *Value) Generate(context.Context) (*Result, error) {
-	val, err := v.refStore.GetAnyValue(v.dir, v.val)
+func (v *Value) Generate(ctx context.Context) (*Result, error) {
+	val, err := v.refStore.GetAnyValue(ctx, v.dir, v.val)
 	if err != nil {
 		return nil, err
 	}
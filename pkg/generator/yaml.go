@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/chancez/yamlforge/pkg/yamldoc"
 )
 
 var _ Generator = (*YAML)(nil)
@@ -24,11 +25,18 @@ func NewYAML(dir string, cfg config.YAMLGenerator, refStore *Store) *YAML {
 	}
 }
 
-func (y *YAML) Generate(context.Context) (*Result, error) {
-	var out bytes.Buffer
-	enc := config.NewYAMLEncoderWithIndent(&out, y.cfg.Indent)
+func (y *YAML) Generate(ctx context.Context) (*Result, error) {
+	preserve, err := y.refStore.GetBoolValue(ctx, y.dir, y.cfg.Preserve)
+	if err != nil {
+		return nil, fmt.Errorf("error getting preserve: %w", err)
+	}
+	if preserve {
+		return y.generatePreserved(ctx)
+	}
+
+	var docs []any
 	for _, input := range y.cfg.Input {
-		vals, err := y.refStore.GetParsedValues(y.dir, input)
+		vals, err := y.refStore.GetParsedValues(ctx, y.dir, input)
 		if err != nil {
 			return nil, fmt.Errorf("error getting value: %w", err)
 		}
@@ -36,15 +44,87 @@ func (y *YAML) Generate(context.Context) (*Result, error) {
 			if err != nil {
 				return nil, fmt.Errorf("error while processing input: %w", err)
 			}
-			err = enc.Encode(val.Parsed())
-			if err != nil {
-				return nil, fmt.Errorf("error writing YAML: %w", err)
+			docs = append(docs, val.Parsed())
+		}
+	}
+	out, err := config.EncodeYAMLDocuments(docs, y.cfg.YAMLFormat)
+	if err != nil {
+		return nil, fmt.Errorf("error writing YAML: %w", err)
+	}
+	return &Result{Output: out, Format: "yaml"}, nil
+}
+
+// generatePreserved returns the inputs which are YAML as they are, and
+// encodes the other inputs as YAML.
+func (y *YAML) generatePreserved(ctx context.Context) (*Result, error) {
+	var out bytes.Buffer
+	for _, input := range y.cfg.Input {
+		data, err := y.preservedInput(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		if out.Len() != 0 && !bytes.HasPrefix(data, []byte("---")) {
+			out.WriteString("---\n")
+		}
+		out.Write(data)
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			out.WriteString("\n")
+		}
+	}
+	formatted, err := config.FormatYAML(out.Bytes(), y.cfg.YAMLFormat)
+	if err != nil {
+		return nil, fmt.Errorf("error writing YAML: %w", err)
+	}
+	return &Result{Output: formatted, Format: "yaml"}, nil
+}
+
+func (y *YAML) preservedInput(ctx context.Context, input config.Value) ([]byte, error) {
+	if input.Format == "" || input.Format == "yaml" {
+		res, err := y.refStore.GetValue(ctx, y.dir, input)
+		if err != nil {
+			return nil, fmt.Errorf("error getting value: %w", err)
+		}
+		if data, ok := yamlText(res); ok {
+			// Parse the input to ensure it's valid YAML.
+			if _, err := yamldoc.Parse(data); err != nil {
+				return nil, fmt.Errorf("error parsing input: %w", err)
 			}
+			return data, nil
 		}
 	}
-	err := enc.Close()
+	vals, err := y.refStore.GetParsedValues(ctx, y.dir, input)
+	if err != nil {
+		return nil, fmt.Errorf("error getting value: %w", err)
+	}
+	var docs []any
+	for val, err := range vals {
+		if err != nil {
+			return nil, fmt.Errorf("error while processing input: %w", err)
+		}
+		docs = append(docs, val.Parsed())
+	}
+	// Formatting is applied to the whole output.
+	out, err := config.EncodeYAMLDocuments(docs, config.YAMLFormat{Indent: y.cfg.Indent})
 	if err != nil {
 		return nil, fmt.Errorf("error writing YAML: %w", err)
 	}
-	return &Result{Output: out.Bytes(), Format: "yaml"}, nil
+	return out, nil
+}
+
+// yamlText returns the output of res if it's unparsed YAML.
+func yamlText(res *Result) ([]byte, bool) {
+	if res == nil || (res.Format != "" && res.Format != "yaml") {
+		return nil, false
+	}
+	switch out := res.Output.(type) {
+	case []byte:
+		return out, true
+	case string:
+		return []byte(out), true
+	default:
+		return nil, false
+	}
 }
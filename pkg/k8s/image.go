@@ -0,0 +1,34 @@
+package k8s
+
+import "strings"
+
+// Image is a container image reference.
+type Image struct {
+	Name   string
+	Tag    string
+	Digest string
+}
+
+// ParseImage parses a container image reference in the form name[:tag][@digest].
+func ParseImage(s string) Image {
+	var img Image
+	s, img.Digest, _ = strings.Cut(s, "@")
+	// A colon after the last slash separates the tag, otherwise it's part of
+	// the registry host and port.
+	if i := strings.LastIndex(s, ":"); i > strings.LastIndex(s, "/") {
+		s, img.Tag = s[:i], s[i+1:]
+	}
+	img.Name = s
+	return img
+}
+
+func (img Image) String() string {
+	s := img.Name
+	if img.Tag != "" {
+		s += ":" + img.Tag
+	}
+	if img.Digest != "" {
+		s += "@" + img.Digest
+	}
+	return s
+}
@@ -0,0 +1,43 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImage(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Image
+	}{
+		{
+			input: "nginx",
+			want:  Image{Name: "nginx"},
+		},
+		{
+			input: "nginx:1.27",
+			want:  Image{Name: "nginx", Tag: "1.27"},
+		},
+		{
+			input: "registry.example.com:5000/team/app:v1",
+			want:  Image{Name: "registry.example.com:5000/team/app", Tag: "v1"},
+		},
+		{
+			input: "registry.example.com:5000/team/app",
+			want:  Image{Name: "registry.example.com:5000/team/app"},
+		},
+		{
+			input: "ghcr.io/app:v1@sha256:abcd",
+			want:  Image{Name: "ghcr.io/app", Tag: "v1", Digest: "sha256:abcd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ParseImage(tt.input)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.input, got.String())
+		})
+	}
+}
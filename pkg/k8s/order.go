@@ -0,0 +1,99 @@
+package k8s
+
+import (
+	"sort"
+)
+
+// InstallOrder is the order Helm installs resources in, by kind.
+var InstallOrder = []string{
+	"PriorityClass",
+	"Namespace",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"SecretList",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleList",
+	"ClusterRoleBinding",
+	"ClusterRoleBindingList",
+	"Role",
+	"RoleList",
+	"RoleBinding",
+	"RoleBindingList",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+// SortByKind sorts docs by their kind using the order specified, and then by
+// their name. Kinds not in order are sorted after known kinds alphabetically,
+// and documents which are not Kubernetes resources are sorted last, retaining
+// their original order.
+func SortByKind(docs []any, order []string) {
+	positions := make(map[string]int, len(order))
+	for i, kind := range order {
+		positions[kind] = i
+	}
+
+	type sortKey struct {
+		known bool
+		pos   int
+		kind  string
+		name  string
+		isObj bool
+	}
+	getKey := func(doc any) sortKey {
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return sortKey{}
+		}
+		kind, _ := obj["kind"].(string)
+		if kind == "" {
+			return sortKey{}
+		}
+		name, _ := NestedMap(obj, "metadata")["name"].(string)
+		pos, known := positions[kind]
+		return sortKey{known: known, pos: pos, kind: kind, name: name, isObj: true}
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		a, b := getKey(docs[i]), getKey(docs[j])
+		if a.isObj != b.isObj {
+			return a.isObj
+		}
+		if !a.isObj {
+			return false
+		}
+		if a.known != b.known {
+			return a.known
+		}
+		if a.known && a.pos != b.pos {
+			return a.pos < b.pos
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		return a.name < b.name
+	})
+}
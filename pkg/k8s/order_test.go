@@ -0,0 +1,38 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortByKind(t *testing.T) {
+	obj := func(kind, name string) map[string]any {
+		return map[string]any{
+			"kind": kind,
+			"metadata": map[string]any{
+				"name": name,
+			},
+		}
+	}
+	docs := []any{
+		"not-a-resource",
+		obj("Deployment", "b"),
+		obj("MyCustomResource", "a"),
+		obj("Deployment", "a"),
+		obj("AnotherCustomResource", "a"),
+		obj("CustomResourceDefinition", "a"),
+		obj("Namespace", "a"),
+	}
+
+	SortByKind(docs, InstallOrder)
+	assert.Equal(t, []any{
+		obj("Namespace", "a"),
+		obj("CustomResourceDefinition", "a"),
+		obj("Deployment", "a"),
+		obj("Deployment", "b"),
+		obj("AnotherCustomResource", "a"),
+		obj("MyCustomResource", "a"),
+		"not-a-resource",
+	}, docs)
+}
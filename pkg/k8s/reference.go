@@ -0,0 +1,81 @@
+package k8s
+
+// RenameFunc is called with the kind and name of each resource referenced by
+// another resource, and returns the name the reference should be updated to.
+type RenameFunc func(kind, name string) string
+
+// UpdateReferences calls rename for each reference obj contains to another
+// resource by name, such as a Deployment mounting a ConfigMap, and updates the
+// reference to the name returned.
+func UpdateReferences(obj map[string]any, rename RenameFunc) {
+	update := func(m map[string]any, field, kind string) {
+		if m == nil {
+			return
+		}
+		name, ok := m[field].(string)
+		if !ok || name == "" {
+			return
+		}
+		m[field] = rename(kind, name)
+	}
+
+	if podSpec := PodSpec(obj); podSpec != nil {
+		updatePodSpecReferences(podSpec, update)
+	}
+
+	switch obj["kind"] {
+	case "RoleBinding", "ClusterRoleBinding":
+		roleRef := NestedMap(obj, "roleRef")
+		if kind, ok := roleRef["kind"].(string); ok {
+			update(roleRef, "name", kind)
+		}
+		for _, subject := range NestedMaps(obj, "subjects") {
+			if subject["kind"] == "ServiceAccount" {
+				update(subject, "name", "ServiceAccount")
+			}
+		}
+	case "Ingress":
+		update(NestedMap(obj, "spec", "defaultBackend", "service"), "name", "Service")
+		for _, rule := range NestedMaps(obj, "spec", "rules") {
+			for _, path := range NestedMaps(rule, "http", "paths") {
+				update(NestedMap(path, "backend", "service"), "name", "Service")
+			}
+		}
+		for _, tls := range NestedMaps(obj, "spec", "tls") {
+			update(tls, "secretName", "Secret")
+		}
+	case "StatefulSet":
+		update(NestedMap(obj, "spec"), "serviceName", "Service")
+	case "HorizontalPodAutoscaler":
+		target := NestedMap(obj, "spec", "scaleTargetRef")
+		if kind, ok := target["kind"].(string); ok {
+			update(target, "name", kind)
+		}
+	}
+}
+
+func updatePodSpecReferences(podSpec map[string]any, update func(m map[string]any, field, kind string)) {
+	update(podSpec, "serviceAccountName", "ServiceAccount")
+	for _, secret := range NestedMaps(podSpec, "imagePullSecrets") {
+		update(secret, "name", "Secret")
+	}
+	for _, volume := range NestedMaps(podSpec, "volumes") {
+		update(NestedMap(volume, "configMap"), "name", "ConfigMap")
+		update(NestedMap(volume, "secret"), "secretName", "Secret")
+		update(NestedMap(volume, "persistentVolumeClaim"), "claimName", "PersistentVolumeClaim")
+		for _, source := range NestedMaps(volume, "projected", "sources") {
+			update(NestedMap(source, "configMap"), "name", "ConfigMap")
+			update(NestedMap(source, "secret"), "name", "Secret")
+		}
+	}
+	for _, container := range Containers(podSpec) {
+		for _, env := range NestedMaps(container, "env") {
+			update(NestedMap(env, "valueFrom", "configMapKeyRef"), "name", "ConfigMap")
+			update(NestedMap(env, "valueFrom", "secretKeyRef"), "name", "Secret")
+		}
+		for _, envFrom := range NestedMaps(container, "envFrom") {
+			update(NestedMap(envFrom, "configMapRef"), "name", "ConfigMap")
+			update(NestedMap(envFrom, "secretRef"), "name", "Secret")
+		}
+	}
+}
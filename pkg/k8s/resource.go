@@ -0,0 +1,40 @@
+package k8s
+
+import (
+	"fmt"
+)
+
+// ResourceID identifies a Kubernetes resource.
+type ResourceID struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+}
+
+func (id ResourceID) String() string {
+	name := id.Name
+	if id.Namespace != "" {
+		name = id.Namespace + "/" + id.Name
+	}
+	return fmt.Sprintf("%s %s %s", id.APIVersion, id.Kind, name)
+}
+
+// GetResourceID returns the identity of obj. It returns false if obj is not a
+// Kubernetes resource, meaning it is missing an apiVersion, kind or name.
+func GetResourceID(obj map[string]any) (ResourceID, bool) {
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(map[string]any)
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	if apiVersion == "" || kind == "" || name == "" {
+		return ResourceID{}, false
+	}
+	return ResourceID{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  namespace,
+		Name:       name,
+	}, true
+}
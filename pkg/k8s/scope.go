@@ -0,0 +1,53 @@
+package k8s
+
+// clusterScopedKinds are the built-in Kubernetes kinds which are not namespaced.
+var clusterScopedKinds = map[string]bool{
+	"APIService":                       true,
+	"CertificateSigningRequest":        true,
+	"ClusterRole":                      true,
+	"ClusterRoleBinding":               true,
+	"ComponentStatus":                  true,
+	"CSIDriver":                        true,
+	"CSINode":                          true,
+	"CustomResourceDefinition":         true,
+	"FlowSchema":                       true,
+	"IngressClass":                     true,
+	"MutatingWebhookConfiguration":     true,
+	"Namespace":                        true,
+	"Node":                             true,
+	"PersistentVolume":                 true,
+	"PodSecurityPolicy":                true,
+	"PriorityClass":                    true,
+	"PriorityLevelConfiguration":       true,
+	"RuntimeClass":                     true,
+	"StorageClass":                     true,
+	"ValidatingAdmissionPolicy":        true,
+	"ValidatingAdmissionPolicyBinding": true,
+	"ValidatingWebhookConfiguration":   true,
+	"VolumeAttachment":                 true,
+}
+
+// IsClusterScoped returns true if kind is a built-in cluster-scoped kind.
+func IsClusterScoped(kind string) bool {
+	return clusterScopedKinds[kind]
+}
+
+// ClusterScopedCRDKinds returns the kinds of the custom resources defined by
+// cluster-scoped CustomResourceDefinitions in objs.
+func ClusterScopedCRDKinds(objs []map[string]any) map[string]bool {
+	kinds := make(map[string]bool)
+	for _, obj := range objs {
+		if obj["kind"] != "CustomResourceDefinition" {
+			continue
+		}
+		spec, _ := obj["spec"].(map[string]any)
+		if spec["scope"] != "Cluster" {
+			continue
+		}
+		names, _ := spec["names"].(map[string]any)
+		if kind, ok := names["kind"].(string); ok {
+			kinds[kind] = true
+		}
+	}
+	return kinds
+}
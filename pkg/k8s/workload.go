@@ -0,0 +1,106 @@
+package k8s
+
+// NestedMap returns the map at the path specified by fields, or nil if it does
+// not exist.
+func NestedMap(obj map[string]any, fields ...string) map[string]any {
+	cur := obj
+	for _, field := range fields {
+		next, ok := cur[field].(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = next
+	}
+	return cur
+}
+
+// EnsureNestedMap returns the map at the path specified by fields, creating
+// any missing maps along the way. It returns nil if a field along the path
+// exists but is not a map.
+func EnsureNestedMap(obj map[string]any, fields ...string) map[string]any {
+	cur := obj
+	for _, field := range fields {
+		val, exists := cur[field]
+		if !exists || val == nil {
+			next := make(map[string]any)
+			cur[field] = next
+			cur = next
+			continue
+		}
+		next, ok := val.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = next
+	}
+	return cur
+}
+
+// NestedMaps returns the elements of the list at the path specified by fields
+// which are maps.
+func NestedMaps(obj map[string]any, fields ...string) []map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+	parent := NestedMap(obj, fields[:len(fields)-1]...)
+	list, _ := parent[fields[len(fields)-1]].([]any)
+	var ret []map[string]any
+	for _, item := range list {
+		if m, ok := item.(map[string]any); ok {
+			ret = append(ret, m)
+		}
+	}
+	return ret
+}
+
+// podTemplatePaths maps workload kinds to the path of their pod template.
+var podTemplatePaths = map[string][]string{
+	"CronJob":               {"spec", "jobTemplate", "spec", "template"},
+	"DaemonSet":             {"spec", "template"},
+	"Deployment":            {"spec", "template"},
+	"Job":                   {"spec", "template"},
+	"PodTemplate":           {"template"},
+	"ReplicaSet":            {"spec", "template"},
+	"ReplicationController": {"spec", "template"},
+	"StatefulSet":           {"spec", "template"},
+}
+
+// IsWorkload returns true if kind is a built-in kind containing a pod template.
+func IsWorkload(kind string) bool {
+	_, ok := podTemplatePaths[kind]
+	return ok
+}
+
+// PodTemplate returns the pod template of a workload, or nil if obj is not a
+// workload or has no pod template.
+func PodTemplate(obj map[string]any) map[string]any {
+	kind, _ := obj["kind"].(string)
+	fields, ok := podTemplatePaths[kind]
+	if !ok {
+		return nil
+	}
+	return NestedMap(obj, fields...)
+}
+
+// PodSpec returns the pod spec of a Pod or workload, or nil if obj does not
+// contain a pod spec.
+func PodSpec(obj map[string]any) map[string]any {
+	if obj["kind"] == "Pod" {
+		return NestedMap(obj, "spec")
+	}
+	template := PodTemplate(obj)
+	if template == nil {
+		return nil
+	}
+	return NestedMap(template, "spec")
+}
+
+// Containers returns all containers, init containers and ephemeral containers
+// in the pod spec.
+func Containers(podSpec map[string]any) []map[string]any {
+	var containers []map[string]any
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers = append(containers, NestedMaps(podSpec, field)...)
+	}
+	return containers
+}
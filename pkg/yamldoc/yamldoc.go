@@ -0,0 +1,489 @@
+// Package yamldoc implements a YAML document model which retains the
+// comments, key order, styles and anchors of its source, so that documents
+// can be modified and encoded again with minimal changes.
+package yamldoc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/chancez/yamlforge/pkg/config"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// File is a stream of YAML documents.
+type File struct {
+	file *ast.File
+	// indent is the indent used for nodes added to the file.
+	indent int
+}
+
+// Parse parses a stream of YAML documents.
+func Parse(data []byte) (*File, error) {
+	f, err := parser.ParseBytes(data, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	return &File{file: f, indent: detectIndent(f)}, nil
+}
+
+// Bytes returns the YAML encoding of the documents.
+func (f *File) Bytes() []byte {
+	return []byte(f.file.String())
+}
+
+// Values returns the decoded documents.
+func (f *File) Values() ([]any, error) {
+	dec := config.NewYAMLDecoder(bytes.NewBufferString(f.file.String()))
+	var values []any
+	for {
+		var v any
+		err := dec.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+}
+
+// Update modifies the documents so that they decode to values. Only the
+// nodes which differ from values are changed, retaining the comments, key
+// order and anchors of the rest of each document.
+func (f *File) Update(values []any) error {
+	current, err := f.Values()
+	if err != nil {
+		return err
+	}
+	if len(current) != len(f.file.Docs) {
+		// Empty documents are skipped by the decoder, so the documents can't
+		// be matched with their values.
+		current = make([]any, len(f.file.Docs))
+	}
+	for i, val := range values {
+		if i >= len(f.file.Docs) {
+			doc, err := f.newDocument(val)
+			if err != nil {
+				return err
+			}
+			f.file.Docs = append(f.file.Docs, doc)
+			continue
+		}
+		if err := f.updateDocument(f.file.Docs[i], current[i], val); err != nil {
+			return err
+		}
+	}
+	f.file.Docs = f.file.Docs[:len(values)]
+
+	// Changes to anchored values also change their aliases, so verify the
+	// result, replacing any documents which don't match.
+	updated, err := f.Values()
+	if err == nil && len(updated) == len(values) {
+		for i, val := range values {
+			if !Equal(updated[i], val) {
+				if err := f.replaceDocument(f.file.Docs[i], val); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for i, val := range values {
+		if err := f.replaceDocument(f.file.Docs[i], val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *File) updateDocument(doc *ast.DocumentNode, old, val any) error {
+	if doc.Body != nil {
+		body, ok, err := f.update(doc.Body, old, val)
+		if err != nil {
+			return err
+		}
+		if ok {
+			doc.Body = body
+			return nil
+		}
+	}
+	return f.replaceDocument(doc, val)
+}
+
+// replaceDocument replaces the body of doc with val, retaining the comment
+// at the start of the document if val is a mapping or sequence.
+func (f *File) replaceDocument(doc *ast.DocumentNode, val any) error {
+	body, err := f.encode(val)
+	if err != nil {
+		return err
+	}
+	switch body.(type) {
+	case *ast.MappingNode, *ast.SequenceNode:
+		if comment := headComment(doc.Body); comment != nil {
+			if err := body.SetComment(comment); err != nil {
+				return err
+			}
+		}
+	}
+	doc.Body = body
+	return nil
+}
+
+func (f *File) newDocument(val any) (*ast.DocumentNode, error) {
+	data, err := f.marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parser.ParseBytes(append([]byte("---\n"), data...), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Docs[0], nil
+}
+
+// update updates node, which decodes to old, to decode to val. It returns
+// false if node can't be updated in place, and must be replaced by its
+// parent.
+func (f *File) update(node ast.Node, old, val any) (ast.Node, bool, error) {
+	if Equal(old, val) {
+		return node, true, nil
+	}
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		value, ok, err := f.update(n.Value, old, val)
+		if err != nil || !ok {
+			return nil, ok, err
+		}
+		n.Value = value
+		return n, true, nil
+	case *ast.MappingNode:
+		oldMap, oldOk := old.(map[string]any)
+		newMap, newOk := val.(map[string]any)
+		if oldOk && newOk && len(newMap) != 0 && len(n.Values) != 0 && !n.IsFlowStyle {
+			return n, true, f.updateMapping(n, oldMap, newMap)
+		}
+	case *ast.SequenceNode:
+		oldList, oldOk := old.([]any)
+		newList, newOk := val.([]any)
+		if oldOk && newOk && len(newList) != 0 && len(n.Values) != 0 && !n.IsFlowStyle {
+			return n, true, f.updateSequence(n, oldList, newList)
+		}
+	case ast.ScalarNode:
+		if _, isAlias := n.(*ast.AliasNode); !isAlias && isScalar(val) {
+			scalar, err := f.encode(val)
+			if err != nil {
+				return nil, false, err
+			}
+			if _, ok := scalar.(ast.ScalarNode); !ok {
+				return nil, false, nil
+			}
+			if comment := n.GetComment(); comment != nil {
+				if err := scalar.SetComment(comment); err != nil {
+					return nil, false, err
+				}
+			}
+			return scalar, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (f *File) updateMapping(n *ast.MappingNode, old, val map[string]any) error {
+	column := n.Values[0].Key.GetToken().Position.Column
+	seen := make(map[string]bool)
+	hasMergeKey := false
+	values := make([]*ast.MappingValueNode, 0, len(n.Values))
+	for _, mv := range n.Values {
+		if _, ok := mv.Key.(*ast.MergeKeyNode); ok {
+			hasMergeKey = true
+			values = append(values, mv)
+			continue
+		}
+		key := keyString(mv.Key)
+		seen[key] = true
+		newVal, exists := val[key]
+		if !exists {
+			continue
+		}
+		value, ok, err := f.update(mv.Value, old[key], newVal)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			replacement, err := f.newMappingValue(key, newVal, column)
+			if err != nil {
+				return err
+			}
+			replacement.Comment = mv.Comment
+			replacement.FootComment = mv.FootComment
+			mv = replacement
+		} else {
+			mv.Value = value
+		}
+		values = append(values, mv)
+	}
+	for _, key := range sortedKeys(val) {
+		if seen[key] {
+			continue
+		}
+		// Keys merged from other mappings only need to be set when they're
+		// modified.
+		if oldVal, exists := old[key]; hasMergeKey && exists && Equal(oldVal, val[key]) {
+			continue
+		}
+		mv, err := f.newMappingValue(key, val[key], column)
+		if err != nil {
+			return err
+		}
+		values = append(values, mv)
+	}
+	n.Values = values
+	return nil
+}
+
+func (f *File) updateSequence(n *ast.SequenceNode, old, val []any) error {
+	column := n.Start.Position.Column
+	hasComments := len(n.ValueHeadComments) == len(n.Values)
+	for i, newVal := range val {
+		if i >= len(n.Values) {
+			item, err := f.newSequenceItem(newVal, column)
+			if err != nil {
+				return err
+			}
+			n.Values = append(n.Values, item)
+			if hasComments {
+				n.ValueHeadComments = append(n.ValueHeadComments, nil)
+			}
+			continue
+		}
+		var oldVal any
+		if i < len(old) {
+			oldVal = old[i]
+		}
+		item, ok, err := f.update(n.Values[i], oldVal, newVal)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			replacement, err := f.newSequenceItem(newVal, column)
+			if err != nil {
+				return err
+			}
+			item = replacement
+		}
+		n.Values[i] = item
+	}
+	n.Values = n.Values[:len(val)]
+	if hasComments {
+		n.ValueHeadComments = n.ValueHeadComments[:len(val)]
+	}
+	return nil
+}
+
+// newMappingValue returns a node for key and val, with the key at column.
+func (f *File) newMappingValue(key string, val any, column int) (*ast.MappingValueNode, error) {
+	node, err := f.encode(yaml.MapSlice{{Key: key, Value: val}})
+	if err != nil {
+		return nil, err
+	}
+	var mv *ast.MappingValueNode
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		mv = n.Values[0]
+	case *ast.MappingValueNode:
+		mv = n
+	default:
+		return nil, fmt.Errorf("unexpected node %s encoding key %q", node.Type(), key)
+	}
+	mv.AddColumn(column - mv.Key.GetToken().Position.Column)
+	return mv, nil
+}
+
+// newSequenceItem returns a node for an item of a sequence at column.
+func (f *File) newSequenceItem(val any, column int) (ast.Node, error) {
+	node, err := f.encode([]any{val})
+	if err != nil {
+		return nil, err
+	}
+	seq, ok := node.(*ast.SequenceNode)
+	if !ok {
+		return nil, fmt.Errorf("unexpected node %s encoding sequence", node.Type())
+	}
+	item := seq.Values[0]
+	item.AddColumn(column - seq.Start.Position.Column)
+	return item, nil
+}
+
+// encode returns the node for val.
+func (f *File) encode(val any) (ast.Node, error) {
+	data, err := f.marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parser.ParseBytes(data, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed.Docs) == 0 || parsed.Docs[0].Body == nil {
+		return nil, errors.New("value encoded to an empty document")
+	}
+	// Top-level sequences are indented by the encoder, so move the node to
+	// the start of the line.
+	body := parsed.Docs[0].Body
+	body.AddColumn(1 - startColumn(body))
+	return body, nil
+}
+
+// startColumn returns the column of the first key or item of node.
+func startColumn(node ast.Node) int {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		if len(n.Values) != 0 {
+			return n.Values[0].Key.GetToken().Position.Column
+		}
+	case *ast.SequenceNode:
+		return n.Start.Position.Column
+	}
+	return node.GetToken().Position.Column
+}
+
+func (f *File) marshal(val any) ([]byte, error) {
+	return yaml.MarshalWithOptions(val, config.NewYAMLEncodeOptions(f.indent)...)
+}
+
+// Equal reports whether two decoded values are equal, treating numbers
+// with the same value as equal regardless of their type.
+func Equal(a, b any) bool {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			other, exists := bv[k]
+			if !exists || !Equal(v, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !Equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// isScalar returns true if val is encoded as a single line scalar.
+func isScalar(val any) bool {
+	switch v := val.(type) {
+	case map[string]any, []any:
+		return false
+	case string:
+		return !strings.Contains(v, "\n")
+	default:
+		return true
+	}
+}
+
+func keyString(key ast.MapKeyNode) string {
+	if s, ok := key.(*ast.StringNode); ok {
+		return s.Value
+	}
+	return key.GetToken().Value
+}
+
+// headComment returns the comment before the first key or item of node.
+func headComment(node ast.Node) *ast.CommentGroupNode {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		if n.Comment != nil {
+			return n.Comment
+		}
+		if len(n.Values) != 0 {
+			return n.Values[0].Comment
+		}
+	case *ast.SequenceNode:
+		return n.Comment
+	}
+	return nil
+}
+
+// detectIndent returns the indent used by the first nested mapping in f, or
+// 0 if there isn't one.
+func detectIndent(f *ast.File) int {
+	indent := 0
+	for _, doc := range f.Docs {
+		ast.Walk(visitorFunc(func(node ast.Node) bool {
+			if indent != 0 {
+				return false
+			}
+			mv, ok := node.(*ast.MappingValueNode)
+			if !ok {
+				return true
+			}
+			m, ok := mv.Value.(*ast.MappingNode)
+			if !ok || m.IsFlowStyle || len(m.Values) == 0 {
+				return true
+			}
+			if diff := m.Values[0].Key.GetToken().Position.Column - mv.Key.GetToken().Position.Column; diff > 0 {
+				indent = diff
+			}
+			return indent == 0
+		}), doc)
+	}
+	return indent
+}
+
+type visitorFunc func(ast.Node) bool
+
+func (fn visitorFunc) Visit(node ast.Node) ast.Visitor {
+	if fn(node) {
+		return fn
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
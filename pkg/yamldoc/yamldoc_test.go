@@ -0,0 +1,210 @@
+package yamldoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const values = `# Values for my-app.
+replicaCount: 1 # keep low
+
+image:
+  repository: nginx # the image
+  tag: "1.25"
+
+defaults: &defaults
+  cpu: 100m
+  memory: 128Mi
+
+# Resources for the app.
+resources:
+  <<: *defaults
+  memory: 256Mi
+
+hosts:
+  - a.example.com
+  # second host
+  - b.example.com
+`
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(doc map[string]any)
+		want   string
+	}{
+		{
+			name:   "unchanged",
+			update: func(map[string]any) {},
+			want:   values,
+		},
+		{
+			name: "scalar",
+			update: func(doc map[string]any) {
+				doc["replicaCount"] = 3
+				doc["image"].(map[string]any)["tag"] = "1.27"
+			},
+			want: `# Values for my-app.
+replicaCount: 3 # keep low
+
+image:
+  repository: nginx # the image
+  tag: "1.27"
+
+defaults: &defaults
+  cpu: 100m
+  memory: 128Mi
+
+# Resources for the app.
+resources:
+  <<: *defaults
+  memory: 256Mi
+
+hosts:
+  - a.example.com
+  # second host
+  - b.example.com
+`,
+		},
+		{
+			name: "add and remove keys",
+			update: func(doc map[string]any) {
+				delete(doc["image"].(map[string]any), "tag")
+				doc["image"].(map[string]any)["pullPolicy"] = "Always"
+				doc["service"] = map[string]any{"port": 80}
+			},
+			want: `# Values for my-app.
+replicaCount: 1 # keep low
+
+image:
+  repository: nginx # the image
+  pullPolicy: Always
+
+defaults: &defaults
+  cpu: 100m
+  memory: 128Mi
+
+# Resources for the app.
+resources:
+  <<: *defaults
+  memory: 256Mi
+
+hosts:
+  - a.example.com
+  # second host
+  - b.example.com
+service:
+  port: 80
+`,
+		},
+		{
+			name: "merge key",
+			update: func(doc map[string]any) {
+				doc["resources"].(map[string]any)["cpu"] = "200m"
+			},
+			want: `# Values for my-app.
+replicaCount: 1 # keep low
+
+image:
+  repository: nginx # the image
+  tag: "1.25"
+
+defaults: &defaults
+  cpu: 100m
+  memory: 128Mi
+
+# Resources for the app.
+resources:
+  <<: *defaults
+  memory: 256Mi
+  cpu: 200m
+
+hosts:
+  - a.example.com
+  # second host
+  - b.example.com
+`,
+		},
+		{
+			name: "sequence",
+			update: func(doc map[string]any) {
+				doc["hosts"] = []any{"a.example.com", "c.example.com", "d.example.com"}
+			},
+			want: `# Values for my-app.
+replicaCount: 1 # keep low
+
+image:
+  repository: nginx # the image
+  tag: "1.25"
+
+defaults: &defaults
+  cpu: 100m
+  memory: 128Mi
+
+# Resources for the app.
+resources:
+  <<: *defaults
+  memory: 256Mi
+
+hosts:
+  - a.example.com
+  # second host
+  - c.example.com
+  - d.example.com
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(values))
+			require.NoError(t, err)
+			docs, err := f.Values()
+			require.NoError(t, err)
+			require.Len(t, docs, 1)
+			tt.update(docs[0].(map[string]any))
+			require.NoError(t, f.Update(docs))
+			assert.Equal(t, tt.want, string(f.Bytes()))
+
+			got, err := f.Values()
+			require.NoError(t, err)
+			assert.True(t, Equal(docs, got), "updated file does not decode to the updated values")
+		})
+	}
+}
+
+func TestUpdateAnchor(t *testing.T) {
+	f, err := Parse([]byte(values))
+	require.NoError(t, err)
+	docs, err := f.Values()
+	require.NoError(t, err)
+	// Changing an anchored value changes its aliases too, so the document is
+	// verified after being updated.
+	doc := docs[0].(map[string]any)
+	doc["defaults"].(map[string]any)["cpu"] = "1"
+	require.NoError(t, f.Update(docs))
+	got, err := f.Values()
+	require.NoError(t, err)
+	assert.True(t, Equal(docs, got), "updated file does not decode to the updated values")
+}
+
+func TestUpdateDocuments(t *testing.T) {
+	f, err := Parse([]byte("# first\na: 1\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Update([]any{
+		map[string]any{"a": 2},
+		map[string]any{"b": []any{1, 2}},
+	}))
+	// Nodes use the default indent unless the file contains a nested mapping.
+	assert.Equal(t, "# first\na: 2\n---\nb:\n    - 1\n    - 2\n", string(f.Bytes()))
+
+	require.NoError(t, f.Update([]any{[]any{"replaced"}}))
+	assert.Equal(t, "# first\n- replaced\n", string(f.Bytes()))
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal(map[string]any{"a": uint64(1)}, map[string]any{"a": float64(1)}))
+	assert.False(t, Equal(map[string]any{"a": 1}, map[string]any{"a": "1"}))
+	assert.False(t, Equal([]any{1}, []any{1, 2}))
+}